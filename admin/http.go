@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/rodneyosodo/gophercon/internal/loglevel"
+)
+
+// NewHandler returns the on-demand profiling endpoints of net/http/pprof
+// under /debug/pprof/, including the runtime execution tracer at
+// /debug/pprof/trace, and the log levels at /loglevel.
+func NewHandler(levels *loglevel.Levels) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/loglevel", logLevelHandler(levels))
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/rodneyosodo/gophercon/internal/loglevel"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxLogLevelBody bounds the request body of the log level endpoint.
+const maxLogLevelBody = 4096
+
+// ErrInvalidLogLevel indicates a level that is not debug, info, warn or
+// error.
+var ErrInvalidLogLevel = errors.New("invalid log level")
+
+// setLogLevel applies req to levels.
+func setLogLevel(levels *loglevel.Levels, req *SetLogLevelRequest) error {
+	if req.GetLevel() == "" {
+		if req.GetComponent() == "" {
+			return fmt.Errorf("%w: level is required", ErrInvalidLogLevel)
+		}
+
+		return levels.Reset(req.GetComponent())
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.GetLevel())); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLogLevel, req.GetLevel())
+	}
+
+	return levels.Set(req.GetComponent(), level)
+}
+
+func toLogLevels(levels *loglevel.Levels) *LogLevels {
+	resp := &LogLevels{Level: levelName(levels.Global())}
+	for _, l := range levels.List() {
+		resp.Components = append(resp.Components, &ComponentLogLevel{
+			Component: l.Component,
+			Level:     levelName(l.Level),
+			Override:  l.Override,
+		})
+	}
+
+	return resp
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// logLevelHandler serves the log levels as JSON on GET and changes them on
+// PUT, with a SetLogLevelRequest body such as
+// {"component": "calculator", "level": "debug"}.
+func logLevelHandler(levels *loglevel.Levels) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxLogLevelBody))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+			var req SetLogLevelRequest
+			if err := protojson.Unmarshal(body, &req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+			switch err := setLogLevel(levels, &req); {
+			case errors.Is(err, loglevel.ErrUnknownComponent):
+				http.Error(w, err.Error(), http.StatusNotFound)
+
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		data, err := protojson.Marshal(toLogLevels(levels))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data) //nolint:errcheck // the client is gone
+	}
+}
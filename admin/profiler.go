@@ -0,0 +1,165 @@
+// Package admin implements the operational endpoints of the Calculator
+// server: profiling controls and on-demand pprof and execution traces.
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/grafana/pyroscope-go"
+)
+
+var (
+	// ErrInvalidProfileType is returned for a profile type Pyroscope does
+	// not know.
+	ErrInvalidProfileType = errors.New("invalid profile type")
+
+	// ErrProfilingUnavailable is returned when continuous profiling is
+	// requested but no Pyroscope server is configured.
+	ErrProfilingUnavailable = errors.New("no pyroscope server configured")
+)
+
+var profileTypes = []pyroscope.ProfileType{
+	pyroscope.ProfileCPU,
+	pyroscope.ProfileInuseObjects,
+	pyroscope.ProfileAllocObjects,
+	pyroscope.ProfileInuseSpace,
+	pyroscope.ProfileAllocSpace,
+	pyroscope.ProfileGoroutines,
+	pyroscope.ProfileMutexCount,
+	pyroscope.ProfileMutexDuration,
+	pyroscope.ProfileBlockCount,
+	pyroscope.ProfileBlockDuration,
+}
+
+// Settings are the profiling settings in effect.
+type Settings struct {
+	// Continuous reports whether profiles are uploaded to Pyroscope.
+	Continuous           bool
+	ProfileTypes         []string
+	BlockProfileRate     int
+	MutexProfileFraction int
+}
+
+// Profiler controls continuous profiling with Pyroscope and the block and
+// mutex sample rates of the runtime. It is safe for concurrent use.
+type Profiler struct {
+	mu            sync.Mutex
+	config        pyroscope.Config
+	types         []pyroscope.ProfileType
+	blockRate     int
+	mutexFraction int
+	running       *pyroscope.Profiler
+}
+
+// NewProfiler applies the sample rates and, when serverAddress is set,
+// starts uploading the given profile types to Pyroscope.
+func NewProfiler(applicationName, serverAddress string, types []string, blockRate, mutexFraction int) (*Profiler, error) {
+	p := &Profiler{
+		config: pyroscope.Config{
+			ApplicationName: applicationName,
+			ServerAddress:   serverAddress,
+		},
+	}
+	if serverAddress == "" {
+		types = nil
+	}
+
+	if _, err := p.Update(&types, &blockRate, &mutexFraction); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Settings returns the settings in effect.
+func (p *Profiler) Settings() Settings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.settings()
+}
+
+// Update changes the settings that are not nil. Continuous profiling is
+// restarted with the new types, or stopped when types is empty.
+func (p *Profiler) Update(types *[]string, blockRate, mutexFraction *int) (Settings, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var parsed []pyroscope.ProfileType
+	if types != nil {
+		for _, t := range *types {
+			pt := pyroscope.ProfileType(t)
+			if !slices.Contains(profileTypes, pt) {
+				return p.settings(), fmt.Errorf("%w: %q", ErrInvalidProfileType, t)
+			}
+			parsed = append(parsed, pt)
+		}
+		if len(parsed) > 0 && p.config.ServerAddress == "" {
+			return p.settings(), ErrProfilingUnavailable
+		}
+	}
+
+	if blockRate != nil {
+		runtime.SetBlockProfileRate(*blockRate)
+		p.blockRate = max(*blockRate, 0)
+	}
+	if mutexFraction != nil {
+		runtime.SetMutexProfileFraction(*mutexFraction)
+		p.mutexFraction = max(*mutexFraction, 0)
+	}
+
+	if types != nil {
+		if err := p.restart(parsed); err != nil {
+			return p.settings(), err
+		}
+	}
+
+	return p.settings(), nil
+}
+
+// Stop stops continuous profiling.
+func (p *Profiler) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.restart(nil)
+}
+
+func (p *Profiler) restart(types []pyroscope.ProfileType) error {
+	if p.running != nil {
+		if err := p.running.Stop(); err != nil {
+			return fmt.Errorf("failed to stop pyroscope: %w", err)
+		}
+		p.running, p.types = nil, nil
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	config := p.config
+	config.ProfileTypes = types
+	running, err := pyroscope.Start(config)
+	if err != nil {
+		return fmt.Errorf("failed to start pyroscope: %w", err)
+	}
+	p.running, p.types = running, types
+
+	return nil
+}
+
+func (p *Profiler) settings() Settings {
+	s := Settings{
+		Continuous:           p.running != nil,
+		BlockProfileRate:     p.blockRate,
+		MutexProfileFraction: p.mutexFraction,
+	}
+	for _, t := range p.types {
+		s.ProfileTypes = append(s.ProfileTypes, string(t))
+	}
+
+	return s
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/internal/loglevel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Scope is the scope a principal needs to call the Admin service and the
+// admin HTTP endpoints.
+const Scope = "admin"
+
+var _ AdminServer = (*grpcServer)(nil)
+
+type grpcServer struct {
+	UnimplementedAdminServer
+	profiler *Profiler
+	levels   *loglevel.Levels
+}
+
+// NewGrpcServer returns the Admin service. Callers must be authenticated
+// with Scope, so the server it is registered on needs the authentication
+// interceptors.
+func NewGrpcServer(profiler *Profiler, levels *loglevel.Levels) AdminServer {
+	return &grpcServer{profiler: profiler, levels: levels}
+}
+
+func (s *grpcServer) GetProfiling(ctx context.Context, _ *GetProfilingRequest) (*Profiling, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return toProfiling(s.profiler.Settings()), nil
+}
+
+func (s *grpcServer) UpdateProfiling(ctx context.Context, req *UpdateProfilingRequest) (*Profiling, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		types                    *[]string
+		blockRate, mutexFraction *int
+	)
+	if req.ProfileTypes != nil {
+		types = &req.ProfileTypes.Types
+	}
+	if req.BlockProfileRate != nil {
+		rate := int(req.GetBlockProfileRate())
+		blockRate = &rate
+	}
+	if req.MutexProfileFraction != nil {
+		fraction := int(req.GetMutexProfileFraction())
+		mutexFraction = &fraction
+	}
+
+	settings, err := s.profiler.Update(types, blockRate, mutexFraction)
+	switch {
+	case errors.Is(err, ErrInvalidProfileType):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrProfilingUnavailable):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return toProfiling(settings), nil
+}
+
+func (s *grpcServer) GetLogLevels(ctx context.Context, _ *GetLogLevelsRequest) (*LogLevels, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return toLogLevels(s.levels), nil
+}
+
+func (s *grpcServer) SetLogLevel(ctx context.Context, req *SetLogLevelRequest) (*LogLevels, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	switch err := setLogLevel(s.levels, req); {
+	case errors.Is(err, loglevel.ErrUnknownComponent):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return toLogLevels(s.levels), nil
+}
+
+func (s *grpcServer) authorize(ctx context.Context) error {
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, auth.ErrMissingCredentials.Error())
+	}
+	if !p.HasScope(Scope) {
+		return status.Errorf(codes.PermissionDenied, "missing scope %s", Scope)
+	}
+
+	return nil
+}
+
+func toProfiling(s Settings) *Profiling {
+	return &Profiling{
+		Continuous:           s.Continuous,
+		ProfileTypes:         s.ProfileTypes,
+		BlockProfileRate:     int32(min(s.BlockProfileRate, 1<<31-1)),     //nolint:gosec,mnd // clamped to int32
+		MutexProfileFraction: int32(min(s.MutexProfileFraction, 1<<31-1)), //nolint:gosec,mnd // clamped to int32
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/calculator/idempotency"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+)
+
+// NewGatewayHandler returns an HTTP handler that serves the REST/JSON facade
+// of the Calculator service, e.g. POST /v1/add {"a":1,"b":2}, together with
+// its OpenAPI description at /openapi.json.
+//
+// Requests are forwarded as gRPC calls over conn so that they go through the
+// same interceptors and Service chain as native gRPC clients.
+func NewGatewayHandler(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	gateway := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(headerMatcher))
+	if err := calculator.RegisterCalculatorHandler(ctx, gateway, conn); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gateway)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(calculator.OpenAPISpec)
+	})
+
+	return otelhttp.NewHandler(mux, "gateway"), nil
+}
+
+// headerMatcher forwards the API key and idempotency key headers on top of
+// the headers the gateway forwards by default, which already include
+// Authorization.
+func headerMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, auth.APIKeyHeader) {
+		return auth.APIKeyHeader, true
+	}
+	if strings.EqualFold(key, idempotency.Header) {
+		return idempotency.Header, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
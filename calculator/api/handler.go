@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"connectrpc.com/vanguard"
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewHandler returns an HTTP handler that serves every service registered on
+// server over native gRPC, gRPC-Web and the Connect protocol.
+//
+// gRPC-Web and Connect requests are transcoded into gRPC calls on server, so
+// its interceptors and stats handlers apply to every protocol. Without TLS
+// the handler accepts HTTP/2 over cleartext (h2c) so that native gRPC
+// clients keep working on the same port.
+//
+// NewHandler must be called before server starts serving.
+func NewHandler(server *grpc.Server, h2cEnabled bool) (http.Handler, error) {
+	encoding.RegisterCodec(vanguardgrpc.NewCodec(&vanguard.JSONCodec{
+		MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}))
+
+	transcoder, err := vanguardgrpc.NewTranscoder(server)
+	if err != nil {
+		return nil, err
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isGRPC(r) {
+			server.ServeHTTP(w, r)
+
+			return
+		}
+		transcoder.ServeHTTP(w, r)
+	})
+	if h2cEnabled {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	return handler, nil
+}
+
+// isGRPC reports whether r is a native gRPC request, which is handed to the
+// gRPC server as is instead of going through the transcoder.
+func isGRPC(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+
+	return r.ProtoMajor == 2 &&
+		strings.HasPrefix(contentType, "application/grpc") &&
+		!strings.HasPrefix(contentType, "application/grpc-web")
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+var _ net.Listener = (*PipeListener)(nil)
+
+// PipeListener is an in-process net.Listener whose connections are
+// net.Pipe pairs. It lets the gateway call the gRPC server it fronts without
+// a socket, so nothing outside the process can reach it.
+type PipeListener struct {
+	conns chan net.Conn
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+// NewPipeListener returns a PipeListener accepting connections made with
+// DialContext.
+func NewPipeListener() *PipeListener {
+	return &PipeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+// Accept waits for the next connection.
+func (l *PipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting connections. Connections already accepted stay
+// open.
+func (l *PipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+
+	return nil
+}
+
+// Addr returns a placeholder address.
+func (l *PipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// DialContext connects to the listener, waiting until the connection is
+// accepted, ctx is done or the listener is closed.
+func (l *PipeListener) DialContext(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-ctx.Done():
+		server.Close()
+		client.Close()
+
+		return nil, ctx.Err()
+	case <-l.closed:
+		server.Close()
+		client.Close()
+
+		return nil, net.ErrClosed
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
@@ -2,19 +2,36 @@ package api
 
 import (
 	"context"
+	"errors"
 
+	"github.com/rodneyosodo/gophercon/admin"
 	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/calculator/history"
+	"github.com/rodneyosodo/gophercon/internal/redact"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var _ calculator.CalculatorServer = (*grpcServer)(nil)
 
 type grpcServer struct {
 	calculator.UnimplementedCalculatorServer
-	service calculator.Service
+	service  calculator.Service
+	history  history.Store
+	redactor *redact.Redactor
 }
 
-func NewGrpcServer(service calculator.Service) calculator.CalculatorServer {
-	return &grpcServer{service: service}
+// NewGrpcServer returns the Calculator server. The history RPCs read from
+// store and fail with Unimplemented when it is nil. Callers only see their
+// own calculations unless they hold the admin scope; without authentication
+// callers are not told apart and see every calculation. Calculations are
+// returned subject to redactor: operands and results are left out unless
+// the policy allows them.
+func NewGrpcServer(service calculator.Service, store history.Store, redactor *redact.Redactor) calculator.CalculatorServer {
+	return &grpcServer{service: service, history: store, redactor: redactor}
 }
 
 func (s *grpcServer) Add(ctx context.Context, req *calculator.Request) (*calculator.Response, error) {
@@ -52,3 +69,106 @@ func (s *grpcServer) Divide(ctx context.Context, req *calculator.Request) (*calc
 
 	return &calculator.Response{Result: result}, nil
 }
+
+func (s *grpcServer) ListHistory(ctx context.Context, req *calculator.ListHistoryRequest) (*calculator.ListHistoryResponse, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.Unimplemented, "history is disabled")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	filter := history.Filter{
+		Operation: req.GetOperation(),
+		Principal: req.GetPrincipal(),
+		Status:    req.GetStatus(),
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok && !p.HasScope(admin.Scope) {
+		if filter.Principal != "" && filter.Principal != p.Subject {
+			return nil, status.Errorf(codes.PermissionDenied, "missing scope %s to list the calculations of other principals", admin.Scope)
+		}
+		filter.Principal = p.Subject
+	}
+	if req.GetStartTime() != nil {
+		filter.Start = req.GetStartTime().AsTime()
+	}
+	if req.GetEndTime() != nil {
+		filter.End = req.GetEndTime().AsTime()
+	}
+
+	page, err := s.history.List(ctx, filter, int(req.GetPageSize()), req.GetPageToken())
+	switch {
+	case errors.Is(err, history.ErrInvalidCursor):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, err
+	}
+
+	resp := &calculator.ListHistoryResponse{NextPageToken: page.Next}
+	for _, c := range page.Calculations {
+		resp.Calculations = append(resp.Calculations, s.toCalculation(c))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcServer) GetCalculation(ctx context.Context, req *calculator.GetCalculationRequest) (*calculator.Calculation, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.Unimplemented, "history is disabled")
+	}
+
+	c, err := s.history.Get(ctx, req.GetId())
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	// The calculations of other principals are reported as missing so that
+	// their IDs cannot be probed.
+	if p, ok := auth.PrincipalFromContext(ctx); ok && !p.HasScope(admin.Scope) && c.Principal != p.Subject {
+		return nil, status.Error(codes.NotFound, history.ErrNotFound.Error())
+	}
+
+	return s.toCalculation(c), nil
+}
+
+// toCalculation applies the redaction policy to c. Operands and results
+// cannot hold a hash or a mask, so they are left out unless allowed.
+func (s *grpcServer) toCalculation(c history.Calculation) *calculator.Calculation {
+	calc := &calculator.Calculation{
+		Id:        c.ID,
+		Time:      timestamppb.New(c.Time),
+		Principal: s.redact("principal", c.Principal),
+		Operation: c.Operation,
+		Status:    c.Status,
+		Error:     s.redact("error", c.Error),
+		TraceId:   c.TraceID,
+		Duration:  durationpb.New(c.Duration),
+	}
+	if s.redactor.Allowed("a") {
+		calc.A = c.A
+	}
+	if s.redactor.Allowed("b") {
+		calc.B = c.B
+	}
+	if s.redactor.Allowed("result") {
+		calc.Result = c.Result
+	}
+
+	return calc
+}
+
+// redact returns the value of field as the policy allows, empty when it is
+// dropped or empty already.
+func (s *grpcServer) redact(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	value, ok := s.redactor.String(field, value)
+	if !ok {
+		return ""
+	}
+
+	return value
+}
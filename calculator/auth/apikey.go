@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// APIKeyHeader is the metadata key carrying the static API key.
+const APIKeyHeader = "x-api-key"
+
+var _ Authenticator = (*apiKeyAuthenticator)(nil)
+
+type apiKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]Principal
+}
+
+// NewAPIKeyAuthenticator returns an Authenticator that checks the
+// x-api-key metadata against a static set of keys.
+//
+// Each entry has the form "key:subject[:scope,scope...]".
+func NewAPIKeyAuthenticator(entries []string) (Authenticator, error) {
+	keys := make(map[[sha256.Size]byte]Principal, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, p, err := parseAPIKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		keys[sha256.Sum256([]byte(key))] = p
+	}
+
+	return &apiKeyAuthenticator{keys: keys}, nil
+}
+
+// LoadAPIKeys reads API key entries from a file, one per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadAPIKeys(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open api keys file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read api keys file: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (a *apiKeyAuthenticator) Authenticate(_ context.Context, md metadata.MD) (Principal, error) {
+	values := md.Get(APIKeyHeader)
+	if len(values) == 0 {
+		return Principal{}, ErrMissingCredentials
+	}
+
+	// Keys are looked up by their digest so that the comparison does not
+	// leak how much of a guessed key was correct.
+	p, ok := a.keys[sha256.Sum256([]byte(values[0]))]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return p, nil
+}
+
+func parseAPIKey(entry string) (string, Principal, error) {
+	const minParts = 2
+
+	parts := strings.SplitN(entry, ":", 3) //nolint:mnd // key, subject and optional scopes
+	if len(parts) < minParts || parts[0] == "" || parts[1] == "" {
+		return "", Principal{}, fmt.Errorf("invalid api key entry %q: expected key:subject[:scopes]", redactKey(entry))
+	}
+
+	p := Principal{Subject: parts[1], Method: "apikey"}
+	if len(parts) == minParts+1 {
+		for _, scope := range strings.Split(parts[2], ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				p.Scopes = append(p.Scopes, scope)
+			}
+		}
+	}
+
+	return parts[0], p, nil
+}
+
+func redactKey(entry string) string {
+	if key, rest, ok := strings.Cut(entry, ":"); ok && key != "" {
+		return "***:" + rest
+	}
+
+	return "***"
+}
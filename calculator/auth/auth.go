@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	// ErrMissingCredentials is returned by an Authenticator when the request
+	// does not carry the credentials it knows how to verify.
+	ErrMissingCredentials = errors.New("missing credentials")
+
+	// ErrInvalidCredentials is returned by an Authenticator when the request
+	// carries credentials that could not be verified.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated identity of a caller.
+type Principal struct {
+	// Subject uniquely identifies the caller.
+	Subject string
+	// Scopes are the permissions granted to the caller.
+	Scopes []string
+	// Method is the authentication method used, e.g. "apikey" or "jwt".
+	Method string
+}
+
+// HasScope reports whether the principal was granted the given scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator verifies the credentials carried in the request metadata.
+// It returns ErrMissingCredentials when the request carries no credentials
+// it understands so that the next Authenticator in the chain can be tried.
+type Authenticator interface {
+	Authenticate(ctx context.Context, md metadata.MD) (Principal, error)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the given principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+
+	return p, ok
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// HTTPMiddleware authenticates HTTP requests with the same credentials as
+// gRPC calls, read from the Authorization and X-API-Key headers, and
+// requires the principal to hold scope when it is not empty. Requests pass
+// through unchecked when no authenticator is given.
+func HTTPMiddleware(next http.Handler, scope string, authenticators ...Authenticator) http.Handler {
+	if len(authenticators) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := metadata.MD{}
+		for key, values := range r.Header {
+			md.Append(strings.ToLower(key), values...)
+		}
+
+		p, err := Authenticate(r.Context(), md, authenticators...)
+		if err != nil {
+			if !errors.Is(err, ErrMissingCredentials) {
+				err = ErrInvalidCredentials
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+
+			return
+		}
+		if scope != "" && !p.HasScope(scope) {
+			http.Error(w, "missing scope "+scope, http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
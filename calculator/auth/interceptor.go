@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticate runs the authenticators in order and returns the principal of
+// the first one that accepts the credentials in md. Authenticators that find
+// no credentials they understand are skipped.
+func Authenticate(ctx context.Context, md metadata.MD, authenticators ...Authenticator) (Principal, error) {
+	for _, authenticator := range authenticators {
+		p, err := authenticator.Authenticate(ctx, md)
+		switch {
+		case err == nil:
+			return p, nil
+		case errors.Is(err, ErrMissingCredentials):
+			continue
+		default:
+			return Principal{}, err
+		}
+	}
+
+	return Principal{}, ErrMissingCredentials
+}
+
+// UnaryServerInterceptor returns a unary interceptor that rejects calls
+// which none of the authenticators accept and stores the authenticated
+// principal in the context of those that pass.
+func UnaryServerInterceptor(authenticators ...Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authenticators)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(authenticators ...Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authenticators)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, authenticators []Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	p, err := Authenticate(ctx, md, authenticators...)
+	if err != nil {
+		if errors.Is(err, ErrMissingCredentials) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return nil, status.Error(codes.Unauthenticated, ErrInvalidCredentials.Error())
+	}
+
+	return WithPrincipal(ctx, p), nil
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx // the stream context is overridden to carry the principal
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+	leeway              = 30 * time.Second
+)
+
+var (
+	_ Authenticator = (*jwtAuthenticator)(nil)
+
+	signatureAlgorithms = []jose.SignatureAlgorithm{
+		jose.RS256, jose.RS384, jose.RS512,
+		jose.PS256, jose.PS384, jose.PS512,
+		jose.ES256, jose.ES384, jose.ES512,
+		jose.EdDSA,
+	}
+)
+
+type jwtAuthenticator struct {
+	keys     jose.JSONWebKeySet
+	issuer   string
+	audience string
+}
+
+// scopeClaims holds the non-registered claims carrying the caller's scopes.
+// Both the space separated "scope" claim (RFC 8693) and the "scp" array used
+// by some identity providers are supported.
+type scopeClaims struct {
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+}
+
+// NewJWTAuthenticator returns an Authenticator that verifies bearer tokens
+// against the keys in a local JWKS file. Issuer and audience are only
+// checked when non-empty.
+func NewJWTAuthenticator(jwksFile, issuer, audience string) (Authenticator, error) {
+	data, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, errors.New("jwks file contains no keys")
+	}
+
+	return &jwtAuthenticator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(_ context.Context, md metadata.MD) (Principal, error) {
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 || !strings.HasPrefix(strings.ToLower(values[0]), bearerPrefix) {
+		return Principal{}, ErrMissingCredentials
+	}
+	raw := strings.TrimSpace(values[0][len(bearerPrefix):])
+
+	token, err := jwt.ParseSigned(raw, signatureAlgorithms)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	var (
+		claims jwt.Claims
+		scopes scopeClaims
+	)
+	if err := token.Claims(a.keys, &claims, &scopes); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	expected := jwt.Expected{Issuer: a.issuer, Time: time.Now()}
+	if a.audience != "" {
+		expected.AnyAudience = jwt.Audience{a.audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, leeway); err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	if claims.Expiry == nil {
+		return Principal{}, fmt.Errorf("%w: token has no expiry", ErrInvalidCredentials)
+	}
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	p := Principal{Subject: claims.Subject, Method: "jwt", Scopes: scopes.Scp}
+	if scopes.Scope != "" {
+		p.Scopes = append(p.Scopes, strings.Fields(scopes.Scope)...)
+	}
+
+	return p, nil
+}
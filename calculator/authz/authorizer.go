@@ -0,0 +1,60 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/internal/filewatch"
+)
+
+// Authorizer evaluates calls against a policy loaded from a file.
+// The policy is swapped atomically on reload so that in-flight calls keep
+// using the version they started with.
+type Authorizer struct {
+	file   string
+	logger *slog.Logger
+	policy atomic.Pointer[Policy]
+}
+
+// NewAuthorizer loads the policy file and returns an Authorizer for it.
+func NewAuthorizer(file string, logger *slog.Logger) (*Authorizer, error) {
+	a := &Authorizer{file: file, logger: logger}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Reload re-reads the policy file. On error the current policy is kept.
+func (a *Authorizer) Reload() error {
+	policy, err := LoadPolicy(a.file)
+	if err != nil {
+		return err
+	}
+	a.policy.Store(policy)
+
+	return nil
+}
+
+// Watch reloads the policy whenever the file changes until ctx is done.
+func (a *Authorizer) Watch(ctx context.Context, interval time.Duration) {
+	filewatch.Watch(ctx, interval, func() {
+		if err := a.Reload(); err != nil {
+			a.logger.Error("Failed to reload authorization policy", slog.String("file", a.file), slog.String("error", err.Error()))
+
+			return
+		}
+		a.logger.Info("Authorization policy reloaded", slog.String("file", a.file))
+	}, a.file)
+}
+
+// Authorize evaluates the current policy for the caller in ctx.
+func (a *Authorizer) Authorize(ctx context.Context, fullMethod string) Decision {
+	principal, authenticated := auth.PrincipalFromContext(ctx)
+
+	return a.policy.Load().Evaluate(principal, authenticated, fullMethod)
+}
@@ -0,0 +1,242 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const policyYAML = `
+default: deny
+rules:
+  - name: no-divide-for-guests
+    principals: [guest]
+    methods: [Divide]
+    effect: deny
+  - name: admin-history
+    principals: ["*"]
+    methods: ["/calculator.Calculator/*History"]
+    scopes: [admin]
+    effect: allow
+  - name: arithmetic
+    principals: ["*"]
+    methods: [Add, Subtract, Multiply, Divide]
+    effect: allow
+  - name: public-add
+    methods: [Add]
+    effect: allow
+`
+
+// writePolicy writes data to a policy file and returns its path.
+func writePolicy(t *testing.T, data string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return file
+}
+
+func TestLoadPolicy(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		// wantErr lists substrings of the error, none when it loads.
+		wantErr []string
+	}{
+		{name: "valid", data: policyYAML},
+		{name: "empty default denies", data: "rules: []"},
+		{name: "unknown field", data: "default: deny\nrulez: []", wantErr: []string{"failed to parse policy file"}},
+		{name: "invalid default", data: "default: maybe", wantErr: []string{`invalid default effect "maybe"`}},
+		{
+			name: "invalid rules are all reported",
+			data: "rules:\n  - name: a\n    effect: permit\n    methods: [Add]\n  - effect: allow\n  - name: c\n    effect: deny\n    methods: [\"[\"]",
+			wantErr: []string{
+				`rule a: invalid effect "permit"`,
+				"rule #1: no methods",
+				`rule c: invalid method pattern "["`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadPolicy(writePolicy(t, tc.data))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("LoadPolicy() error = %v", err)
+				}
+
+				return
+			}
+			if err == nil {
+				t.Fatalf("LoadPolicy() error = nil, want %q", tc.wantErr)
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadPolicy() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadPolicy() of a missing file error = nil")
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, policyYAML))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		principal     auth.Principal
+		authenticated bool
+		method        string
+		allowed       bool
+		rule          string
+	}{
+		{
+			name:          "deny rule wins over a later allow",
+			principal:     auth.Principal{Subject: "guest"},
+			authenticated: true,
+			method:        calculator.Calculator_Divide_FullMethodName,
+			rule:          "no-divide-for-guests",
+		},
+		{
+			name:          "wildcard principal",
+			principal:     auth.Principal{Subject: "alice"},
+			authenticated: true,
+			method:        calculator.Calculator_Divide_FullMethodName,
+			allowed:       true,
+			rule:          "arithmetic",
+		},
+		{
+			name:          "pattern with scope",
+			principal:     auth.Principal{Subject: "alice", Scopes: []string{"admin"}},
+			authenticated: true,
+			method:        calculator.Calculator_ListHistory_FullMethodName,
+			allowed:       true,
+			rule:          "admin-history",
+		},
+		{
+			name:          "missing scope",
+			principal:     auth.Principal{Subject: "alice"},
+			authenticated: true,
+			method:        calculator.Calculator_ListHistory_FullMethodName,
+			rule:          "admin-history",
+		},
+		{
+			name:    "unauthenticated caller skips principal rules",
+			method:  calculator.Calculator_Add_FullMethodName,
+			allowed: true,
+			rule:    "public-add",
+		},
+		{
+			name:   "unauthenticated caller falls back to the default",
+			method: calculator.Calculator_Subtract_FullMethodName,
+			rule:   "default",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policy.Evaluate(tc.principal, tc.authenticated, tc.method)
+			if got.Allowed != tc.allowed || got.Rule != tc.rule {
+				t.Errorf("Evaluate() = %+v, want allowed %v by %s", got, tc.allowed, tc.rule)
+			}
+		})
+	}
+
+	open := &Policy{Default: EffectAllow}
+	if got := open.Evaluate(auth.Principal{}, false, calculator.Calculator_Add_FullMethodName); !got.Allowed {
+		t.Errorf("Evaluate() with an allow default = %+v, want allowed", got)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	authorizer, err := NewAuthorizer(writePolicy(t, policyYAML), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewAuthorizer() error = %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		principal string
+		method    string
+		want      codes.Code
+	}{
+		{name: "allowed", principal: "alice", method: calculator.Calculator_Divide_FullMethodName, want: codes.OK},
+		{name: "denied", principal: "guest", method: calculator.Calculator_Divide_FullMethodName, want: codes.PermissionDenied},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var audit bytes.Buffer
+			interceptor := UnaryServerInterceptor(authorizer, slog.New(slog.NewTextHandler(&audit, nil)))
+
+			called := false
+			handler := func(context.Context, any) (any, error) {
+				called = true
+
+				return &calculator.Response{}, nil
+			}
+			ctx := auth.WithPrincipal(context.Background(), auth.Principal{Subject: tc.principal})
+			_, err := interceptor(ctx, &calculator.Request{}, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			if code := status.Code(err); code != tc.want {
+				t.Fatalf("code = %s, want %s (%v)", code, tc.want, err)
+			}
+			if called != (tc.want == codes.OK) {
+				t.Errorf("handler called = %v", called)
+			}
+			if denied := strings.Contains(audit.String(), "Authorization denied"); denied != (tc.want != codes.OK) {
+				t.Errorf("audit = %q", audit.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizerReload(t *testing.T) {
+	file := writePolicy(t, "default: allow")
+	authorizer, err := NewAuthorizer(file, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewAuthorizer() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err := os.WriteFile(file, []byte("default: maybe"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := authorizer.Reload(); err == nil {
+		t.Fatal("Reload() of an invalid policy error = nil")
+	}
+	if !authorizer.Authorize(ctx, calculator.Calculator_Add_FullMethodName).Allowed {
+		t.Error("invalid policy replaced the current one")
+	}
+
+	if err := os.WriteFile(file, []byte("default: deny"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := authorizer.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if authorizer.Authorize(ctx, calculator.Calculator_Add_FullMethodName).Allowed {
+		t.Error("valid policy was not loaded")
+	}
+}
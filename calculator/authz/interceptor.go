@@ -0,0 +1,52 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a unary interceptor that enforces the
+// authorizer's policy. Denied calls fail with PermissionDenied and are
+// recorded on the audit logger.
+func UnaryServerInterceptor(authorizer *Authorizer, audit *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, authorizer, audit, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(authorizer *Authorizer, audit *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), authorizer, audit, info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+func authorize(ctx context.Context, authorizer *Authorizer, audit *slog.Logger, fullMethod string) error {
+	decision := authorizer.Authorize(ctx, fullMethod)
+	if decision.Allowed {
+		return nil
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	audit.WarnContext(ctx, "Authorization denied",
+		slog.String("method", fullMethod),
+		slog.String("principal", principal.Subject),
+		slog.String("rule", decision.Rule),
+		slog.String("reason", decision.Reason),
+	)
+
+	return status.Error(codes.PermissionDenied, ErrDenied.Error())
+}
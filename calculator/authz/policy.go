@@ -0,0 +1,167 @@
+package authz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EffectAllow permits the call.
+	EffectAllow Effect = "allow"
+	// EffectDeny rejects the call.
+	EffectDeny Effect = "deny"
+
+	wildcard = "*"
+)
+
+// ErrDenied is returned when the policy does not allow a call.
+var ErrDenied = errors.New("permission denied")
+
+// Effect is the outcome of a matching rule.
+type Effect string
+
+// Rule grants or denies a set of principals access to a set of methods.
+//
+// Methods are either full gRPC method names ("/calculator.Calculator/Add"),
+// bare method names ("Add") or path.Match patterns ("/calculator.Calculator/*").
+// An empty Principals list matches every caller, "*" matches every
+// authenticated caller. When Scopes is set, an allow rule additionally
+// requires the principal to hold all of them.
+type Rule struct {
+	Name       string   `yaml:"name"`
+	Principals []string `yaml:"principals"`
+	Methods    []string `yaml:"methods"`
+	Scopes     []string `yaml:"scopes"`
+	Effect     Effect   `yaml:"effect"`
+}
+
+// Policy is an ordered list of rules. The first rule matching the principal
+// and method decides; Default applies when no rule matches.
+type Policy struct {
+	Default Effect `yaml:"default"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Decision is the result of evaluating a policy.
+type Decision struct {
+	Allowed bool
+	Rule    string
+	Reason  string
+}
+
+// LoadPolicy reads and validates a YAML policy file.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var policy Policy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Validate checks that the policy is well formed.
+func (p *Policy) Validate() error {
+	var errs []error
+
+	switch p.Default {
+	case "":
+		p.Default = EffectDeny
+	case EffectAllow, EffectDeny:
+	default:
+		errs = append(errs, fmt.Errorf("invalid default effect %q", p.Default))
+	}
+
+	for i, rule := range p.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			errs = append(errs, fmt.Errorf("rule %s: invalid effect %q", name, rule.Effect))
+		}
+		if len(rule.Methods) == 0 {
+			errs = append(errs, fmt.Errorf("rule %s: no methods", name))
+		}
+		for _, method := range rule.Methods {
+			if _, err := path.Match(method, ""); err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: invalid method pattern %q: %w", name, method, err))
+			}
+		}
+		p.Rules[i].Name = name
+	}
+
+	return errors.Join(errs...)
+}
+
+// Evaluate decides whether the principal may call the given full method.
+// authenticated is false when the call carries no principal.
+func (p *Policy) Evaluate(principal auth.Principal, authenticated bool, fullMethod string) Decision {
+	for _, rule := range p.Rules {
+		if !rule.matchesPrincipal(principal, authenticated) || !rule.matchesMethod(fullMethod) {
+			continue
+		}
+
+		if rule.Effect == EffectDeny {
+			return Decision{Allowed: false, Rule: rule.Name, Reason: "denied by rule"}
+		}
+		for _, scope := range rule.Scopes {
+			if !principal.HasScope(scope) {
+				return Decision{Allowed: false, Rule: rule.Name, Reason: "missing scope " + scope}
+			}
+		}
+
+		return Decision{Allowed: true, Rule: rule.Name}
+	}
+
+	if p.Default == EffectAllow {
+		return Decision{Allowed: true, Rule: "default"}
+	}
+
+	return Decision{Allowed: false, Rule: "default", Reason: "no matching rule"}
+}
+
+func (r Rule) matchesPrincipal(principal auth.Principal, authenticated bool) bool {
+	if len(r.Principals) == 0 {
+		return true
+	}
+	if !authenticated {
+		return false
+	}
+
+	return slices.Contains(r.Principals, wildcard) || slices.Contains(r.Principals, principal.Subject)
+}
+
+func (r Rule) matchesMethod(fullMethod string) bool {
+	shortMethod := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, pattern := range r.Methods {
+		name := fullMethod
+		if !strings.HasPrefix(pattern, "/") {
+			name = shortMethod
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
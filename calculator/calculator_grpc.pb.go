@@ -19,10 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Calculator_Add_FullMethodName      = "/calculator.Calculator/Add"
-	Calculator_Subtract_FullMethodName = "/calculator.Calculator/Subtract"
-	Calculator_Multiply_FullMethodName = "/calculator.Calculator/Multiply"
-	Calculator_Divide_FullMethodName   = "/calculator.Calculator/Divide"
+	Calculator_Add_FullMethodName            = "/calculator.Calculator/Add"
+	Calculator_Subtract_FullMethodName       = "/calculator.Calculator/Subtract"
+	Calculator_Multiply_FullMethodName       = "/calculator.Calculator/Multiply"
+	Calculator_Divide_FullMethodName         = "/calculator.Calculator/Divide"
+	Calculator_ListHistory_FullMethodName    = "/calculator.Calculator/ListHistory"
+	Calculator_GetCalculation_FullMethodName = "/calculator.Calculator/GetCalculation"
 )
 
 // CalculatorClient is the client API for Calculator service.
@@ -35,6 +37,10 @@ type CalculatorClient interface {
 	Subtract(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
 	Multiply(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
 	Divide(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
+	// ListHistory lists completed calculations, most recent first.
+	ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*ListHistoryResponse, error)
+	// GetCalculation returns one completed calculation.
+	GetCalculation(ctx context.Context, in *GetCalculationRequest, opts ...grpc.CallOption) (*Calculation, error)
 }
 
 type calculatorClient struct {
@@ -85,6 +91,26 @@ func (c *calculatorClient) Divide(ctx context.Context, in *Request, opts ...grpc
 	return out, nil
 }
 
+func (c *calculatorClient) ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*ListHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListHistoryResponse)
+	err := c.cc.Invoke(ctx, Calculator_ListHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) GetCalculation(ctx context.Context, in *GetCalculationRequest, opts ...grpc.CallOption) (*Calculation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Calculation)
+	err := c.cc.Invoke(ctx, Calculator_GetCalculation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalculatorServer is the server API for Calculator service.
 // All implementations must embed UnimplementedCalculatorServer
 // for forward compatibility
@@ -95,6 +121,10 @@ type CalculatorServer interface {
 	Subtract(context.Context, *Request) (*Response, error)
 	Multiply(context.Context, *Request) (*Response, error)
 	Divide(context.Context, *Request) (*Response, error)
+	// ListHistory lists completed calculations, most recent first.
+	ListHistory(context.Context, *ListHistoryRequest) (*ListHistoryResponse, error)
+	// GetCalculation returns one completed calculation.
+	GetCalculation(context.Context, *GetCalculationRequest) (*Calculation, error)
 	mustEmbedUnimplementedCalculatorServer()
 }
 
@@ -114,6 +144,12 @@ func (UnimplementedCalculatorServer) Multiply(context.Context, *Request) (*Respo
 func (UnimplementedCalculatorServer) Divide(context.Context, *Request) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Divide not implemented")
 }
+func (UnimplementedCalculatorServer) ListHistory(context.Context, *ListHistoryRequest) (*ListHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListHistory not implemented")
+}
+func (UnimplementedCalculatorServer) GetCalculation(context.Context, *GetCalculationRequest) (*Calculation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCalculation not implemented")
+}
 func (UnimplementedCalculatorServer) mustEmbedUnimplementedCalculatorServer() {}
 
 // UnsafeCalculatorServer may be embedded to opt out of forward compatibility for this service.
@@ -199,6 +235,42 @@ func _Calculator_Divide_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calculator_ListHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).ListHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calculator_ListHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).ListHistory(ctx, req.(*ListHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_GetCalculation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCalculationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).GetCalculation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calculator_GetCalculation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).GetCalculation(ctx, req.(*GetCalculationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Calculator_ServiceDesc is the grpc.ServiceDesc for Calculator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -222,6 +294,14 @@ var Calculator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Divide",
 			Handler:    _Calculator_Divide_Handler,
 		},
+		{
+			MethodName: "ListHistory",
+			Handler:    _Calculator_ListHistory_Handler,
+		},
+		{
+			MethodName: "GetCalculation",
+			Handler:    _Calculator_GetCalculation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "calculator/calculator.proto",
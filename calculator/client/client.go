@@ -0,0 +1,195 @@
+// Package client provides a Go client for the Calculator gRPC service.
+//
+// Client implements calculator.Service, so a remote calculator can be used
+// anywhere a local one is expected:
+//
+//	c, err := client.New(client.WithAddress("calculator:6000"), client.WithAPIKey(key))
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	var svc calculator.Service = c
+//	result, err := svc.Add(ctx, 18, 3)
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var _ calculator.Service = (*Client)(nil)
+
+// Client is a pooled connection to a Calculator server.
+type Client struct {
+	conns   []*grpc.ClientConn
+	clients []calculator.CalculatorClient
+	next    atomic.Uint64
+	timeout time.Duration
+}
+
+// New creates a Client. Connections are established lazily on first use.
+func New(opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	dialOptions, err := o.dialOptionsFor()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{timeout: o.timeout}
+	for range o.poolSize {
+		conn, err := grpc.NewClient(o.address, dialOptions...)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to create connection: %w", err), c.Close())
+		}
+		c.conns = append(c.conns, conn)
+		c.clients = append(c.clients, calculator.NewCalculatorClient(conn))
+	}
+
+	return c, nil
+}
+
+// Close closes every pooled connection.
+func (c *Client) Close() error {
+	var errs []error
+	for _, conn := range c.conns {
+		errs = append(errs, conn.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Client) Add(ctx context.Context, a, b int64) (int64, error) {
+	return c.call(ctx, calculator.CalculatorClient.Add, a, b)
+}
+
+func (c *Client) Subtract(ctx context.Context, a, b int64) (int64, error) {
+	return c.call(ctx, calculator.CalculatorClient.Subtract, a, b)
+}
+
+func (c *Client) Multiply(ctx context.Context, a, b int64) (int64, error) {
+	return c.call(ctx, calculator.CalculatorClient.Multiply, a, b)
+}
+
+func (c *Client) Divide(ctx context.Context, a, b int64) (int64, error) {
+	return c.call(ctx, calculator.CalculatorClient.Divide, a, b)
+}
+
+type method func(calculator.CalculatorClient, context.Context, *calculator.Request, ...grpc.CallOption) (*calculator.Response, error)
+
+func (c *Client) call(ctx context.Context, m method, a, b int64) (int64, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	client := c.clients[c.next.Add(1)%uint64(len(c.clients))]
+	resp, err := m(client, ctx, &calculator.Request{A: a, B: b})
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.GetResult(), nil
+}
+
+func (o options) dialOptionsFor() ([]grpc.DialOption, error) {
+	var handlerOptions []otelgrpc.Option
+	if o.tracerProvider != nil {
+		handlerOptions = append(handlerOptions, otelgrpc.WithTracerProvider(o.tracerProvider))
+	}
+	if o.meterProvider != nil {
+		handlerOptions = append(handlerOptions, otelgrpc.WithMeterProvider(o.meterProvider))
+	}
+
+	dialOptions := []grpc.DialOption{grpc.WithStatsHandler(otelgrpc.NewClientHandler(handlerOptions...))}
+
+	switch o.tlsConfig {
+	case nil:
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	default:
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(o.tlsConfig)))
+	}
+
+	if o.credentials != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(o.credentials))
+	}
+
+	if o.retry != nil {
+		serviceConfig, err := o.retry.serviceConfig()
+		if err != nil {
+			return nil, err
+		}
+		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	return append(dialOptions, o.dialOptions...), nil
+}
+
+// serviceConfig renders the retry policy as a gRPC service config applying
+// to every method of the Calculator service.
+func (r retryPolicy) serviceConfig() (string, error) {
+	type name struct {
+		Service string `json:"service"`
+	}
+	type policy struct {
+		MaxAttempts          int     `json:"maxAttempts"`
+		InitialBackoff       string  `json:"initialBackoff"`
+		MaxBackoff           string  `json:"maxBackoff"`
+		BackoffMultiplier    float64 `json:"backoffMultiplier"`
+		RetryableStatusCodes []int   `json:"retryableStatusCodes"`
+	}
+	type methodConfig struct {
+		Name        []name `json:"name"`
+		RetryPolicy policy `json:"retryPolicy"`
+	}
+
+	const backoffMultiplier = 2
+
+	codes := make([]int, len(r.codes))
+	for i, code := range r.codes {
+		codes[i] = int(code)
+	}
+
+	config := map[string][]methodConfig{
+		"methodConfig": {{
+			Name: []name{{Service: calculator.Calculator_ServiceDesc.ServiceName}},
+			RetryPolicy: policy{
+				MaxAttempts:          r.maxAttempts,
+				InitialBackoff:       seconds(r.initialBackoff),
+				MaxBackoff:           seconds(r.maxBackoff),
+				BackoffMultiplier:    backoffMultiplier,
+				RetryableStatusCodes: codes,
+			},
+		}},
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal retry service config: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
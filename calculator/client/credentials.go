@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	_ credentials.PerRPCCredentials = (*apiKeyCredentials)(nil)
+	_ credentials.PerRPCCredentials = (*bearerCredentials)(nil)
+)
+
+type apiKeyCredentials struct {
+	key string
+}
+
+func (c *apiKeyCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{auth.APIKeyHeader: c.key}, nil
+}
+
+// RequireTransportSecurity is false so that API keys can be used against
+// the plaintext listener of a local compose stack.
+func (c *apiKeyCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+type bearerCredentials struct {
+	token string
+}
+
+func (c *bearerCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c *bearerCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	defaultAddress        = "localhost:6000"
+	defaultPoolSize       = 1
+	defaultMaxAttempts    = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = time.Second
+)
+
+// Option configures a Client.
+type Option func(*options)
+
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	codes          []codes.Code
+}
+
+type options struct {
+	address        string
+	tlsConfig      *tls.Config
+	credentials    credentials.PerRPCCredentials
+	retry          *retryPolicy
+	timeout        time.Duration
+	poolSize       int
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	dialOptions    []grpc.DialOption
+	err            error
+}
+
+func defaultOptions() options {
+	return options{
+		address:  defaultAddress,
+		poolSize: defaultPoolSize,
+	}
+}
+
+// WithAddress sets the address of the Calculator server. It defaults to localhost:6000.
+func WithAddress(address string) Option {
+	return func(o *options) {
+		o.address = address
+	}
+}
+
+// WithTLS enables TLS using the given configuration. Set Certificates on the
+// configuration to authenticate with a client certificate when the server
+// requires mTLS. Without this option the connection is plaintext.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithTLSFiles enables TLS, verifying the server against the PEM encoded CA
+// certificates in caFile. When certFile and keyFile are set, the client
+// authenticates with that certificate for mTLS.
+func WithTLSFiles(caFile, certFile, keyFile string) Option {
+	return func(o *options) {
+		config, err := loadTLSConfig(caFile, certFile, keyFile)
+		if err != nil {
+			o.err = err
+
+			return
+		}
+		o.tlsConfig = config
+	}
+}
+
+// WithAPIKey authenticates every call with a static API key.
+func WithAPIKey(key string) Option {
+	return func(o *options) {
+		o.credentials = &apiKeyCredentials{key: key}
+	}
+}
+
+// WithBearerToken authenticates every call with a JWT bearer token.
+func WithBearerToken(token string) Option {
+	return func(o *options) {
+		o.credentials = &bearerCredentials{token: token}
+	}
+}
+
+// WithPerRPCCredentials authenticates every call with custom credentials,
+// e.g. to refresh tokens.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(o *options) {
+		o.credentials = creds
+	}
+}
+
+// WithRetry enables transparent retries of failed calls with exponential
+// backoff through the gRPC retry service config. Calls are retried on
+// Unavailable unless other status codes are given.
+func WithRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration, retryable ...codes.Code) Option {
+	return func(o *options) {
+		if len(retryable) == 0 {
+			retryable = []codes.Code{codes.Unavailable}
+		}
+		o.retry = &retryPolicy{
+			maxAttempts:    maxAttempts,
+			initialBackoff: initialBackoff,
+			maxBackoff:     maxBackoff,
+			codes:          retryable,
+		}
+	}
+}
+
+// WithDefaultRetry enables retries on Unavailable with sensible defaults.
+func WithDefaultRetry() Option {
+	return WithRetry(defaultMaxAttempts, defaultInitialBackoff, defaultMaxBackoff)
+}
+
+// WithTimeout bounds every call that does not already carry a shorter deadline.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithPoolSize spreads calls over size connections in round robin.
+// A single HTTP/2 connection caps the number of concurrent streams, so
+// high-throughput callers benefit from more than one.
+func WithPoolSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.poolSize = size
+		}
+	}
+}
+
+// WithTracerProvider sets the tracer provider used to trace calls.
+// It defaults to the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider used to record call metrics.
+// It defaults to the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *options) {
+		o.meterProvider = provider
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+func loadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("ca certificate file contains no certificates")
+	}
+
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,108 @@
+// Package deadline bounds how long the server works on a calculator call.
+//
+// Calls whose caller set no deadline get the default deadline of their
+// operation; deadlines set by callers are honoured as they are. Operations
+// returning a context error are answered with DeadlineExceeded or Canceled
+// rather than Unknown, and deadline overruns are counted per operation.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const scope = "github.com/rodneyosodo/gophercon/calculator/deadline"
+
+// Config holds the default deadline of each operation. Zero means none.
+type Config struct {
+	Add      time.Duration
+	Subtract time.Duration
+	Multiply time.Duration
+	Divide   time.Duration
+}
+
+func (c Config) forMethod(method string) (time.Duration, bool) {
+	switch method {
+	case calculator.Calculator_Add_FullMethodName:
+		return c.Add, true
+	case calculator.Calculator_Subtract_FullMethodName:
+		return c.Subtract, true
+	case calculator.Calculator_Multiply_FullMethodName:
+		return c.Multiply, true
+	case calculator.Calculator_Divide_FullMethodName:
+		return c.Divide, true
+	default:
+		return 0, false
+	}
+}
+
+// Deadlines holds the default deadlines, which can be changed while calls
+// are served.
+type Deadlines struct {
+	config   atomic.Pointer[Config]
+	exceeded metric.Int64Counter
+}
+
+// New returns Deadlines applying cfg and counting overruns on provider.
+func New(cfg Config, provider metric.MeterProvider) (*Deadlines, error) {
+	exceeded, err := provider.Meter(scope).Int64Counter("calculator.deadline_exceeded",
+		metric.WithUnit("{call}"), metric.WithDescription("Calculator calls that ended with DeadlineExceeded."))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Deadlines{exceeded: exceeded}
+	d.Set(cfg)
+
+	return d, nil
+}
+
+// Set replaces the default deadlines. Calls in flight keep theirs.
+func (d *Deadlines) Set(cfg Config) {
+	d.config.Store(&cfg)
+}
+
+// Timeout returns the default deadline of method, zero when it has none.
+func (d *Deadlines) Timeout(method string) time.Duration {
+	timeout, _ := d.config.Load().forMethod(method)
+
+	return timeout
+}
+
+// UnaryServerInterceptor applies the default deadlines to calculator calls.
+// It should come before the interceptors that may wait, such as
+// authorization or deduplication, so that the deadline covers them.
+func UnaryServerInterceptor(d *Deadlines) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		timeout, ok := d.config.Load().forMethod(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+		if _, set := ctx.Deadline(); !set && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		resp, err := handler(ctx, req)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			err = status.FromContextError(err).Err()
+		}
+		if status.Code(err) == codes.DeadlineExceeded {
+			operation := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+			d.exceeded.Add(ctx, 1, metric.WithAttributes(attribute.String("operation", operation)))
+		}
+
+		return resp, err
+	}
+}
@@ -0,0 +1,178 @@
+package deadline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exceeded returns the overruns counted on reader, by operation.
+func exceeded(t *testing.T, reader sdkmetric.Reader) map[string]int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok || m.Name != "calculator.deadline_exceeded" {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				operation, _ := dp.Attributes.Value(attribute.Key("operation"))
+				counts[operation.AsString()] += dp.Value
+			}
+		}
+	}
+
+	return counts
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	// waitDone returns the context error once ctx is done, or a response
+	// after wait.
+	waitDone := func(wait time.Duration) grpc.UnaryHandler {
+		return func(ctx context.Context, _ any) (any, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+				return &calculator.Response{}, nil
+			}
+		}
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		// callerTimeout is the deadline set by the caller, none when zero.
+		callerTimeout time.Duration
+		handler       grpc.UnaryHandler
+		want          codes.Code
+		// overruns are the overruns counted, by operation.
+		overruns map[string]int64
+	}{
+		{
+			name:     "default deadline applies",
+			method:   calculator.Calculator_Divide_FullMethodName,
+			handler:  waitDone(time.Minute),
+			want:     codes.DeadlineExceeded,
+			overruns: map[string]int64{"Divide": 1},
+		},
+		{
+			name:    "call within the default deadline",
+			method:  calculator.Calculator_Divide_FullMethodName,
+			handler: waitDone(0),
+			want:    codes.OK,
+		},
+		{
+			name:          "caller deadline is honoured",
+			method:        calculator.Calculator_Divide_FullMethodName,
+			callerTimeout: time.Minute,
+			handler:       waitDone(50 * time.Millisecond),
+			want:          codes.OK,
+		},
+		{
+			name:    "operation without a default deadline",
+			method:  calculator.Calculator_Add_FullMethodName,
+			handler: waitDone(50 * time.Millisecond),
+			want:    codes.OK,
+		},
+		{
+			name:   "canceled is not an overrun",
+			method: calculator.Calculator_Add_FullMethodName,
+			handler: func(context.Context, any) (any, error) {
+				return nil, context.Canceled
+			},
+			want: codes.Canceled,
+		},
+		{
+			name:   "status errors are returned as is",
+			method: calculator.Calculator_Add_FullMethodName,
+			handler: func(context.Context, any) (any, error) {
+				return nil, status.Error(codes.InvalidArgument, "division by zero")
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name:   "other methods are not bounded",
+			method: calculator.Calculator_ListHistory_FullMethodName,
+			handler: func(ctx context.Context, _ any) (any, error) {
+				if _, ok := ctx.Deadline(); ok {
+					return nil, status.Error(codes.Internal, "deadline set")
+				}
+
+				return &calculator.Response{}, nil
+			},
+			want: codes.OK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			d, err := New(Config{Divide: 10 * time.Millisecond}, sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			ctx := context.Background()
+			if tc.callerTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.callerTimeout)
+				defer cancel()
+			}
+			_, err = UnaryServerInterceptor(d)(ctx, &calculator.Request{}, &grpc.UnaryServerInfo{FullMethod: tc.method}, tc.handler)
+			if code := status.Code(err); code != tc.want {
+				t.Fatalf("code = %s, want %s (%v)", code, tc.want, err)
+			}
+
+			got := exceeded(t, reader)
+			if len(got) != len(tc.overruns) {
+				t.Errorf("overruns = %v, want %v", got, tc.overruns)
+			}
+			for operation, want := range tc.overruns {
+				if got[operation] != want {
+					t.Errorf("overruns[%s] = %d, want %d", operation, got[operation], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadlinesSet(t *testing.T) {
+	d, err := New(Config{Add: time.Second}, sdkmetric.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := d.Timeout(calculator.Calculator_Add_FullMethodName); got != time.Second {
+		t.Errorf("Timeout(Add) = %s, want 1s", got)
+	}
+
+	d.Set(Config{Multiply: time.Minute})
+	cases := []struct {
+		method string
+		want   time.Duration
+	}{
+		{method: calculator.Calculator_Add_FullMethodName},
+		{method: calculator.Calculator_Multiply_FullMethodName, want: time.Minute},
+		{method: calculator.Calculator_GetCalculation_FullMethodName},
+	}
+	for _, tc := range cases {
+		if got := d.Timeout(tc.method); got != tc.want {
+			t.Errorf("Timeout(%s) = %s, want %s", tc.method, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// faultPrecision is the resolution of the error rate.
+const faultPrecision = 1_000_000
+
+// FaultProfile is the fault injected into every calculator operation.
+type FaultProfile struct {
+	// ErrorRate is the share of calls failing with a random error.
+	ErrorRate float64
+	// Latency is added to every call.
+	Latency time.Duration
+}
+
+// Faults holds the current fault profile. It is safe for concurrent use and
+// can be updated while calls are served.
+type Faults struct {
+	profile atomic.Pointer[FaultProfile]
+}
+
+// NewFaults returns Faults injecting profile.
+func NewFaults(profile FaultProfile) *Faults {
+	f := &Faults{}
+	f.Set(profile)
+
+	return f
+}
+
+// Set replaces the fault profile. Calls in flight keep the previous one.
+func (f *Faults) Set(profile FaultProfile) {
+	f.profile.Store(&profile)
+}
+
+// Profile returns the current fault profile.
+func (f *Faults) Profile() FaultProfile {
+	return *f.profile.Load()
+}
+
+// inject waits for the configured latency and then fails at the configured
+// rate.
+func (f *Faults) inject(ctx context.Context) error {
+	profile := f.Profile()
+
+	if err := wait(ctx, profile.Latency); err != nil {
+		return err
+	}
+
+	if float64(generateRandomNumber(faultPrecision)) < profile.ErrorRate*faultPrecision {
+		return errors.New("random error")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Package history stores completed calculations so that callers can look up
+// what they computed earlier and real traffic can be replayed.
+package history
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a list request does not set one.
+	DefaultPageSize = 50
+	// MaxPageSize caps the size of a page.
+	MaxPageSize = 1000
+)
+
+var (
+	// ErrNotFound indicates a calculation that is not in the store.
+	ErrNotFound = errors.New("calculation not found")
+	// ErrInvalidCursor indicates a page token the store did not issue.
+	ErrInvalidCursor = errors.New("invalid page token")
+)
+
+// Calculation is a completed call to one of the operations.
+type Calculation struct {
+	// ID is assigned by the store. IDs increase with the time calculations
+	// are saved.
+	ID        string
+	Time      time.Time
+	Principal string
+	Operation string
+	A         int64
+	B         int64
+	Result    int64
+	// Status is the gRPC status code name the call ended with, e.g. OK.
+	Status   string
+	Error    string
+	TraceID  string
+	Duration time.Duration
+}
+
+// Filter selects calculations. Zero fields match everything.
+type Filter struct {
+	Operation string
+	Principal string
+	Status    string
+	// Start and End bound the time of the calculations, inclusive and
+	// exclusive respectively.
+	Start time.Time
+	End   time.Time
+}
+
+// Page is one page of calculations, most recent first.
+type Page struct {
+	Calculations []Calculation
+	// Next is the cursor of the following page, empty on the last page.
+	Next string
+}
+
+// Store persists calculations. Implementations must be safe for concurrent
+// use.
+type Store interface {
+	// Save stores c and returns it with its ID.
+	Save(ctx context.Context, c Calculation) (Calculation, error)
+	// Get returns the calculation with id or ErrNotFound.
+	Get(ctx context.Context, id string) (Calculation, error)
+	// List returns up to size calculations matching filter, starting after
+	// cursor, which is empty for the first page.
+	List(ctx context.Context, filter Filter, size int, cursor string) (Page, error)
+	Close() error
+}
+
+// PageSize returns size bounded to (0, MaxPageSize], DefaultPageSize when
+// it is not positive.
+func PageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+
+	return min(size, MaxPageSize)
+}
+
+// encodeCursor returns the opaque cursor of the page after the calculation
+// with the numeric id.
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,181 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the sqlite driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS calculations (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	time       INTEGER NOT NULL,
+	principal  TEXT    NOT NULL,
+	operation  TEXT    NOT NULL,
+	a          INTEGER NOT NULL,
+	b          INTEGER NOT NULL,
+	result     INTEGER NOT NULL,
+	status     TEXT    NOT NULL,
+	error      TEXT    NOT NULL,
+	trace_id   TEXT    NOT NULL,
+	duration   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS calculations_time ON calculations (time);
+CREATE INDEX IF NOT EXISTS calculations_principal ON calculations (principal, id);
+`
+
+const columns = `id, time, principal, operation, a, b, result, status, error, trace_id, duration`
+
+// busyTimeout is how long a write waits for another connection to release
+// the database.
+const busyTimeout = 5 * time.Second
+
+var _ Store = (*SQLite)(nil)
+
+// SQLite stores calculations in an embedded SQLite database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite opens, and creates if needed, the SQLite database at path.
+func NewSQLite(ctx context.Context, path string) (*SQLite, error) {
+	// WAL lets the history be listed while calculations are saved.
+	dsn := (&url.URL{Scheme: "file", Opaque: path, RawQuery: url.Values{
+		"_pragma": {"journal_mode(WAL)", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds())},
+	}.Encode()}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("failed to create history schema: %w", err)
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Save(ctx context.Context, c Calculation) (Calculation, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO calculations (time, principal, operation, a, b, result, status, error, trace_id, duration)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.Time.UnixNano(), c.Principal, c.Operation, c.A, c.B, c.Result, c.Status, c.Error, c.TraceID, int64(c.Duration),
+	)
+	if err != nil {
+		return Calculation{}, fmt.Errorf("failed to save calculation: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Calculation{}, fmt.Errorf("failed to save calculation: %w", err)
+	}
+	c.ID = strconv.FormatInt(id, 10)
+
+	return c, nil
+}
+
+func (s *SQLite) Get(ctx context.Context, id string) (Calculation, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return Calculation{}, ErrNotFound
+	}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM calculations WHERE id = ?`, n)
+	c, err := scanCalculation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Calculation{}, ErrNotFound
+	}
+
+	return c, err
+}
+
+func (s *SQLite) List(ctx context.Context, filter Filter, size int, cursor string) (Page, error) {
+	var conds []string
+	var args []any
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		conds, args = append(conds, "id < ?"), append(args, after)
+	}
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"operation", filter.Operation},
+		{"principal", filter.Principal},
+		{"status", filter.Status},
+	} {
+		if f.value != "" {
+			conds, args = append(conds, f.column+" = ?"), append(args, f.value)
+		}
+	}
+	if !filter.Start.IsZero() {
+		conds, args = append(conds, "time >= ?"), append(args, filter.Start.UnixNano())
+	}
+	if !filter.End.IsZero() {
+		conds, args = append(conds, "time < ?"), append(args, filter.End.UnixNano())
+	}
+
+	query := `SELECT ` + columns + ` FROM calculations`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	// One more row than requested tells whether there is a next page.
+	size = PageSize(size)
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, size+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to list calculations: %w", err)
+	}
+	defer rows.Close()
+
+	var page Page
+	for rows.Next() {
+		c, err := scanCalculation(rows)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Calculations = append(page.Calculations, c)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, fmt.Errorf("failed to list calculations: %w", err)
+	}
+
+	if len(page.Calculations) > size {
+		page.Calculations = page.Calculations[:size]
+		last, _ := strconv.ParseInt(page.Calculations[size-1].ID, 10, 64) // IDs are formatted by scanCalculation
+		page.Next = encodeCursor(last)
+	}
+
+	return page, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func scanCalculation(row interface{ Scan(dest ...any) error }) (Calculation, error) {
+	var c Calculation
+	var id, at, duration int64
+	err := row.Scan(&id, &at, &c.Principal, &c.Operation, &c.A, &c.B, &c.Result, &c.Status, &c.Error, &c.TraceID, &duration)
+	if err != nil {
+		return Calculation{}, err
+	}
+	c.ID = strconv.FormatInt(id, 10)
+	c.Time = time.Unix(0, at).UTC()
+	c.Duration = time.Duration(duration)
+
+	return c, nil
+}
@@ -0,0 +1,196 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// epoch is the time of the first calculation saved by newStore; each
+// following one is a second later.
+var epoch = time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// newStore returns a store holding one calculation per entry of
+// calculations, with the time set from its position.
+func newStore(t *testing.T, calculations []Calculation) *SQLite {
+	t.Helper()
+
+	ctx := context.Background()
+	store, err := NewSQLite(ctx, filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("NewSQLite() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	for i, c := range calculations {
+		c.Time = epoch.Add(time.Duration(i) * time.Second)
+		if _, err := store.Save(ctx, c); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	return store
+}
+
+// listAll follows the cursors from the first page and returns the IDs of
+// every page.
+func listAll(t *testing.T, store *SQLite, filter Filter, size int) [][]string {
+	t.Helper()
+
+	var pages [][]string
+	cursor := ""
+	for {
+		page, err := store.List(context.Background(), filter, size, cursor)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		ids := []string{}
+		for _, c := range page.Calculations {
+			ids = append(ids, c.ID)
+		}
+		pages = append(pages, ids)
+		if page.Next == "" {
+			return pages
+		}
+		cursor = page.Next
+	}
+}
+
+func adds(n int) []Calculation {
+	calculations := make([]Calculation, n)
+	for i := range calculations {
+		calculations[i] = Calculation{Operation: "Add", Status: "OK"}
+	}
+
+	return calculations
+}
+
+func TestSQLiteListPagination(t *testing.T) {
+	cases := []struct {
+		name  string
+		saved int
+		size  int
+		want  [][]string
+	}{
+		{
+			name: "empty",
+			size: 2,
+			want: [][]string{{}},
+		},
+		{
+			name:  "fewer than a page",
+			saved: 1,
+			size:  2,
+			want:  [][]string{{"1"}},
+		},
+		{
+			name:  "exactly a page",
+			saved: 2,
+			size:  2,
+			want:  [][]string{{"2", "1"}},
+		},
+		{
+			name:  "one more than a page",
+			saved: 3,
+			size:  2,
+			want:  [][]string{{"3", "2"}, {"1"}},
+		},
+		{
+			name:  "exactly two pages",
+			saved: 4,
+			size:  2,
+			want:  [][]string{{"4", "3"}, {"2", "1"}},
+		},
+		{
+			name:  "default size",
+			saved: 3,
+			size:  0,
+			want:  [][]string{{"3", "2", "1"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newStore(t, adds(tc.saved))
+
+			got := listAll(t, store, Filter{}, tc.size)
+			if !slices.EqualFunc(got, tc.want, slices.Equal) {
+				t.Errorf("List() pages = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteListFilter(t *testing.T) {
+	store := newStore(t, []Calculation{
+		{Principal: "alice", Operation: "Add", Status: "OK"},
+		{Principal: "bob", Operation: "Divide", Status: "InvalidArgument"},
+		{Principal: "alice", Operation: "Divide", Status: "OK"},
+		{Principal: "bob", Operation: "Add", Status: "OK"},
+		{Principal: "alice", Operation: "Multiply", Status: "Unknown"},
+	})
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name: "none",
+			want: []string{"5", "4", "3", "2", "1"},
+		},
+		{
+			name:   "operation",
+			filter: Filter{Operation: "Divide"},
+			want:   []string{"3", "2"},
+		},
+		{
+			name:   "principal",
+			filter: Filter{Principal: "alice"},
+			want:   []string{"5", "3", "1"},
+		},
+		{
+			name:   "status",
+			filter: Filter{Status: "OK"},
+			want:   []string{"4", "3", "1"},
+		},
+		{
+			name:   "time range",
+			filter: Filter{Start: epoch.Add(time.Second), End: epoch.Add(3 * time.Second)},
+			want:   []string{"3", "2"},
+		},
+		{
+			name:   "combined",
+			filter: Filter{Principal: "alice", Status: "OK", Start: epoch.Add(time.Second)},
+			want:   []string{"3"},
+		},
+		{
+			name:   "no match",
+			filter: Filter{Principal: "carol"},
+			want:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// A page size of 2 checks that the filter holds across pages.
+			got := slices.Concat(listAll(t, store, tc.filter, 2)...)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("List() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteListInvalidCursor(t *testing.T) {
+	store := newStore(t, adds(1))
+
+	for _, cursor := range []string{"not base64!", encodeCursor(0)} {
+		if _, err := store.List(context.Background(), Filter{}, 1, cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("List(%q) error = %v, want %v", cursor, err, ErrInvalidCursor)
+		}
+	}
+}
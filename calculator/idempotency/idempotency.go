@@ -0,0 +1,254 @@
+// Package idempotency lets callers retry calculator calls safely.
+//
+// A call carrying the idempotency-key metadata header is executed once per
+// key and caller: repeating it with the same key within the TTL returns the
+// stored response without executing the operation again, which matters for
+// Multiply, whose upstream API call has side effects, and for clients
+// retrying after DeadlineExceeded. Only successful responses are stored, so
+// failed calls can be retried. Concurrent calls with the same key wait for
+// the first one and share its outcome. The call is executed apart from its
+// caller's context, bounded by the default deadline of its operation, so
+// that its response is stored even when the caller gives up first. Reusing
+// a key with a different request, concurrently or not, is rejected with
+// InvalidArgument.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	scope = "github.com/rodneyosodo/gophercon/calculator/idempotency"
+
+	// Header is the metadata key carrying the idempotency key. Over the REST
+	// gateway it is sent as the Idempotency-Key HTTP header.
+	Header = "idempotency-key"
+
+	// maxKeyLength bounds the keys accepted.
+	maxKeyLength = 255
+)
+
+var (
+	// ErrKeyReused indicates a key already used for a different request.
+	ErrKeyReused = errors.New("idempotency key reused with a different request")
+	// ErrInvalidKey indicates a key that is empty or too long.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+)
+
+// methods are the calls that honour the header.
+var methods = []string{
+	calculator.Calculator_Add_FullMethodName,
+	calculator.Calculator_Subtract_FullMethodName,
+	calculator.Calculator_Multiply_FullMethodName,
+	calculator.Calculator_Divide_FullMethodName,
+}
+
+// Entry is a stored response.
+type Entry struct {
+	// Fingerprint identifies the method and request the response is for.
+	Fingerprint string
+	Response    *anypb.Any
+}
+
+// Store keeps responses by key until their TTL expires. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry stored under key, false when there is none or it
+	// has expired.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Put stores e under key for ttl.
+	Put(ctx context.Context, key string, e Entry, ttl time.Duration) error
+}
+
+// Deduplicator executes each keyed call once per TTL.
+type Deduplicator struct {
+	store    Store
+	ttl      time.Duration
+	timeout  func(method string) time.Duration
+	logger   *slog.Logger
+	inflight singleflight.Group
+	hits     metric.Int64Counter
+}
+
+// NewDeduplicator returns a Deduplicator storing responses in store for ttl
+// and counting the calls answered from it on provider. timeout returns the
+// time a call to method may run once executed; zero, or a nil timeout, bounds
+// it by the deadline of the caller instead. Store failures are logged to
+// logger.
+func NewDeduplicator(store Store, ttl time.Duration, timeout func(method string) time.Duration, logger *slog.Logger, provider metric.MeterProvider) (*Deduplicator, error) {
+	hits, err := provider.Meter(scope).Int64Counter("idempotency.hits",
+		metric.WithUnit("{call}"), metric.WithDescription("Calls answered with the stored response of an earlier call with the same idempotency key."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Deduplicator{store: store, ttl: ttl, timeout: timeout, logger: logger, hits: hits}, nil
+}
+
+// UnaryServerInterceptor deduplicates calculator calls carrying the
+// idempotency-key header. It must run after authentication, since keys are
+// scoped to the caller.
+func UnaryServerInterceptor(d *Deduplicator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		values := metadata.ValueFromIncomingContext(ctx, Header)
+		if len(values) == 0 {
+			return handler(ctx, req)
+		}
+
+		key := values[0]
+		if key == "" || len(key) > maxKeyLength {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("%s: must be 1 to %d characters", ErrInvalidKey, maxKeyLength))
+		}
+
+		return d.do(ctx, info.FullMethod, key, req, handler)
+	}
+}
+
+// outcome is the result of a shared execution.
+type outcome struct {
+	resp any
+	// fingerprint is that of the request resp is for.
+	fingerprint string
+	// caller identifies the call whose request was executed; it is nil when
+	// the response was stored already.
+	caller *int
+}
+
+func (d *Deduplicator) do(ctx context.Context, method, key string, req any, handler grpc.UnaryHandler) (any, error) {
+	fingerprint, err := fingerprint(method, req)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Keys are scoped to the caller so that one caller cannot read the
+	// responses of another.
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		key = p.Subject + "\x00" + key
+	}
+
+	// Concurrent calls with the same key share one execution, or lookup of
+	// the stored response, whatever their request; those whose request does
+	// not match the executed one are rejected.
+	caller := new(int)
+	results := d.inflight.DoChan(key, func() (any, error) {
+		return d.execute(ctx, method, key, fingerprint, caller, req, handler)
+	})
+
+	// Callers stop waiting when their own context ends; the execution goes
+	// on for the others and to store its response.
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case result := <-results:
+		o, _ := result.Val.(outcome)
+		if o.fingerprint != fingerprint {
+			return nil, status.Error(codes.InvalidArgument, ErrKeyReused.Error())
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if o.caller != caller {
+			operation := method[strings.LastIndex(method, "/")+1:]
+			d.hits.Add(ctx, 1, metric.WithAttributes(attribute.String("operation", operation)))
+		}
+
+		return o.resp, nil
+	}
+}
+
+// execute returns the stored response for key or executes handler on behalf
+// of caller and stores its response when it succeeds.
+func (d *Deduplicator) execute(ctx context.Context, method, key, fingerprint string, caller *int, req any, handler grpc.UnaryHandler) (any, error) {
+	ctx, cancel := d.detach(ctx, method)
+	defer cancel()
+
+	entry, ok, err := d.store.Get(ctx, key)
+	if err != nil {
+		return outcome{fingerprint: fingerprint}, status.Error(codes.Internal, err.Error())
+	}
+	if ok {
+		resp, err := entry.Response.UnmarshalNew()
+
+		return outcome{resp: resp, fingerprint: entry.Fingerprint}, err
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return outcome{fingerprint: fingerprint, caller: caller}, err
+	}
+	// The call succeeded regardless of whether the response is stored;
+	// when it is not, retries execute again.
+	if err := d.put(context.WithoutCancel(ctx), key, fingerprint, resp); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to store idempotent response", slog.String("error", err.Error()))
+	}
+
+	return outcome{resp: resp, fingerprint: fingerprint, caller: caller}, nil
+}
+
+// detach returns a context carrying the values of ctx but not its
+// cancellation, bounded by the timeout of method or else by the deadline of
+// ctx.
+func (d *Deduplicator) detach(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if d.timeout != nil {
+		if timeout := d.timeout(method); timeout > 0 {
+			return context.WithTimeout(detached, timeout)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+
+	return context.WithCancel(detached)
+}
+
+func (d *Deduplicator) put(ctx context.Context, key, fingerprint string, resp any) error {
+	m, ok := resp.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unexpected response %T", resp)
+	}
+	stored, err := anypb.New(m)
+	if err != nil {
+		return err
+	}
+
+	return d.store.Put(ctx, key, Entry{Fingerprint: fingerprint, Response: stored}, d.ttl)
+}
+
+// fingerprint hashes the method and the deterministic encoding of req.
+func fingerprint(method string, req any) (string, error) {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return "", fmt.Errorf("unexpected request %T", req)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(method+"\x00"), data...))
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,256 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"go.opentelemetry.io/otel/metric/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var addInfo = &grpc.UnaryServerInfo{FullMethod: calculator.Calculator_Add_FullMethodName}
+
+// newInterceptor returns an interceptor whose executions time out after
+// timeout, none when zero.
+func newInterceptor(t *testing.T, timeout time.Duration) grpc.UnaryServerInterceptor {
+	t.Helper()
+
+	timeouts := func(string) time.Duration { return timeout }
+	d, err := NewDeduplicator(NewMemoryStore(10), time.Minute, timeouts, slog.New(slog.NewTextHandler(io.Discard, nil)), noop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("NewDeduplicator() error = %v", err)
+	}
+
+	return UnaryServerInterceptor(d)
+}
+
+// callContext returns the context of a call by principal, none when empty,
+// carrying key.
+func callContext(principal, key string) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, key))
+	if principal != "" {
+		ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: principal})
+	}
+
+	return ctx
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	type call struct {
+		principal string
+		key       string
+		a, b      int64
+		// fail makes the handler fail the call when it executes it.
+		fail bool
+	}
+
+	cases := []struct {
+		name  string
+		calls []call
+		want  []codes.Code
+		// executions is the number of calls the handler executes.
+		executions int
+	}{
+		{
+			name:       "repeated key is a hit",
+			calls:      []call{{key: "k", a: 1, b: 2}, {key: "k", a: 1, b: 2}},
+			want:       []codes.Code{codes.OK, codes.OK},
+			executions: 1,
+		},
+		{
+			name:       "different keys execute",
+			calls:      []call{{key: "k1", a: 1, b: 2}, {key: "k2", a: 1, b: 2}},
+			want:       []codes.Code{codes.OK, codes.OK},
+			executions: 2,
+		},
+		{
+			name:       "key reused with a different request",
+			calls:      []call{{key: "k", a: 1, b: 2}, {key: "k", a: 1, b: 3}},
+			want:       []codes.Code{codes.OK, codes.InvalidArgument},
+			executions: 1,
+		},
+		{
+			name:       "failed calls are not stored",
+			calls:      []call{{key: "k", a: 1, b: 2, fail: true}, {key: "k", a: 1, b: 2}, {key: "k", a: 1, b: 2}},
+			want:       []codes.Code{codes.Unavailable, codes.OK, codes.OK},
+			executions: 2,
+		},
+		{
+			name:       "keys are scoped to the principal",
+			calls:      []call{{principal: "alice", key: "k", a: 1, b: 2}, {principal: "bob", key: "k", a: 1, b: 2}},
+			want:       []codes.Code{codes.OK, codes.OK},
+			executions: 2,
+		},
+		{
+			name:       "same principal is a hit",
+			calls:      []call{{principal: "alice", key: "k", a: 1, b: 2}, {principal: "alice", key: "k", a: 1, b: 2}},
+			want:       []codes.Code{codes.OK, codes.OK},
+			executions: 1,
+		},
+		{
+			name:       "invalid key",
+			calls:      []call{{key: "", a: 1, b: 2}},
+			want:       []codes.Code{codes.InvalidArgument},
+			executions: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			interceptor := newInterceptor(t, 0)
+			executions := 0
+
+			for i, c := range tc.calls {
+				handler := func(_ context.Context, req any) (any, error) {
+					executions++
+					if c.fail {
+						return nil, status.Error(codes.Unavailable, "upstream unavailable")
+					}
+					r := req.(*calculator.Request)
+
+					return &calculator.Response{Result: r.GetA() + r.GetB()}, nil
+				}
+
+				req := &calculator.Request{A: c.a, B: c.b}
+				resp, err := interceptor(callContext(c.principal, c.key), req, addInfo, handler)
+				if code := status.Code(err); code != tc.want[i] {
+					t.Fatalf("call %d: code = %s, want %s (%v)", i+1, code, tc.want[i], err)
+				}
+				if err != nil {
+					continue
+				}
+				if got := resp.(*calculator.Response).GetResult(); got != c.a+c.b {
+					t.Errorf("call %d: result = %d, want %d", i+1, got, c.a+c.b)
+				}
+			}
+
+			if executions != tc.executions {
+				t.Errorf("executions = %d, want %d", executions, tc.executions)
+			}
+		})
+	}
+}
+
+// blockingHandler returns a handler that counts its executions and answers
+// once release is closed, or fails when its context ends first. started is
+// closed when the first execution begins.
+func blockingHandler(executions *atomic.Int32, started, release chan struct{}) grpc.UnaryHandler {
+	return func(ctx context.Context, req any) (any, error) {
+		if executions.Add(1) == 1 {
+			close(started)
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		r := req.(*calculator.Request)
+
+		return &calculator.Response{Result: r.GetA() + r.GetB()}, nil
+	}
+}
+
+func TestUnaryServerInterceptorAbandonedCall(t *testing.T) {
+	interceptor := newInterceptor(t, 0)
+
+	var executions atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := blockingHandler(&executions, started, release)
+	req := &calculator.Request{A: 1, B: 2}
+
+	leader, cancel := context.WithCancel(callContext("alice", "k"))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := interceptor(leader, req, addInfo, handler); status.Code(err) != codes.Canceled {
+			t.Errorf("abandoned call: error = %v, want %s", err, codes.Canceled)
+		}
+	}()
+	<-started
+
+	// The leader gives up while its call is executing; a call waiting for
+	// it still gets the response once the execution completes.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := interceptor(callContext("alice", "k"), req, addInfo, handler)
+		if err != nil {
+			t.Errorf("waiting call: error = %v", err)
+
+			return
+		}
+		if got := resp.(*calculator.Response).GetResult(); got != 3 {
+			t.Errorf("waiting call: result = %d, want 3", got)
+		}
+	}()
+	cancel()
+	close(release)
+	wg.Wait()
+
+	// The response was stored although the leader was gone.
+	resp, err := interceptor(callContext("alice", "k"), req, addInfo, handler)
+	if err != nil {
+		t.Fatalf("retried call: error = %v", err)
+	}
+	if got := resp.(*calculator.Response).GetResult(); got != 3 {
+		t.Errorf("retried call: result = %d, want 3", got)
+	}
+	if got := executions.Load(); got != 1 {
+		t.Errorf("executions = %d, want 1", got)
+	}
+}
+
+func TestUnaryServerInterceptorConcurrentMismatch(t *testing.T) {
+	interceptor := newInterceptor(t, 0)
+
+	var executions atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := blockingHandler(&executions, started, release)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := interceptor(callContext("", "k"), &calculator.Request{A: 1, B: 2}, addInfo, handler); err != nil {
+			t.Errorf("first call: error = %v", err)
+		}
+	}()
+	<-started
+
+	// The key is reused with another request while the first call runs.
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	_, err := interceptor(callContext("", "k"), &calculator.Request{A: 1, B: 3}, addInfo, handler)
+	wg.Wait()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("second call: error = %v, want %s", err, codes.InvalidArgument)
+	}
+	if got := executions.Load(); got != 1 {
+		t.Errorf("executions = %d, want 1", got)
+	}
+}
+
+func TestUnaryServerInterceptorTimeout(t *testing.T) {
+	interceptor := newInterceptor(t, 10*time.Millisecond)
+
+	var executions atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := blockingHandler(&executions, started, release)
+
+	// The execution is bounded by the timeout although the caller has no
+	// deadline.
+	_, err := interceptor(callContext("", "k"), &calculator.Request{A: 1, B: 2}, addInfo, handler)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("error = %v, want %s", err, codes.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+type memoryEntry struct {
+	entry   Entry
+	expires time.Time
+}
+
+// MemoryStore keeps entries in memory, so they are lost on restart and not
+// shared between replicas. It holds at most maxKeys entries: when full,
+// expired entries are removed and then the one closest to expiry.
+type MemoryStore struct {
+	maxKeys int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore returns an empty MemoryStore holding up to maxKeys entries.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{maxKeys: maxKeys, entries: map[string]memoryEntry{}}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+
+		return Entry{}, false, nil
+	}
+
+	return e.entry, true, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, key string, e Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.maxKeys {
+		s.evict(now)
+	}
+	s.entries[key] = memoryEntry{entry: e, expires: now.Add(ttl)}
+
+	return nil
+}
+
+// evict removes the expired entries, or the entry closest to expiry when
+// none has expired.
+func (s *MemoryStore) evict(now time.Time) {
+	var oldest string
+	var oldestExpires time.Time
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+
+			continue
+		}
+		if oldest == "" || e.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, e.expires
+		}
+	}
+	if len(s.entries) >= s.maxKeys {
+		delete(s.entries, oldest)
+	}
+}
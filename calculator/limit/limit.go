@@ -0,0 +1,91 @@
+// Package limit bounds the number of calculator calls served concurrently.
+// Calls beyond the limit are rejected with ResourceExhausted rather than
+// queued, so that callers can back off or retry elsewhere.
+package limit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrLimitExceeded indicates that the call was rejected because too many
+// calls were in flight.
+var ErrLimitExceeded = errors.New("too many concurrent calls")
+
+// servicePrefix selects the methods the limit applies to. The Admin service
+// stays reachable while the calculator is saturated.
+var servicePrefix = "/" + calculator.Calculator_ServiceDesc.ServiceName + "/"
+
+// Limiter admits calls while fewer than its maximum are in flight. The
+// maximum can be changed while calls are served.
+type Limiter struct {
+	max      atomic.Int64
+	inflight atomic.Int64
+}
+
+// NewLimiter returns a Limiter admitting up to maxCalls concurrent calls.
+// Zero means unlimited.
+func NewLimiter(maxCalls int) *Limiter {
+	l := &Limiter{}
+	l.SetMax(maxCalls)
+
+	return l
+}
+
+// SetMax changes the maximum number of concurrent calls. Calls in flight are
+// not interrupted when it is lowered.
+func (l *Limiter) SetMax(maxCalls int) {
+	l.max.Store(int64(maxCalls))
+}
+
+// acquire reserves a slot and returns the function releasing it.
+func (l *Limiter) acquire() (func(), error) {
+	n := l.inflight.Add(1)
+	if limit := l.max.Load(); limit > 0 && n > limit {
+		l.inflight.Add(-1)
+
+		return nil, status.Error(codes.ResourceExhausted, ErrLimitExceeded.Error())
+	}
+
+	return func() { l.inflight.Add(-1) }, nil
+}
+
+// UnaryServerInterceptor returns a unary interceptor that enforces the
+// limiter on calculator calls.
+func UnaryServerInterceptor(l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(ctx, req)
+		}
+		release, err := l.acquire()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(l *Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(srv, stream)
+		}
+		release, err := l.acquire()
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,127 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// holdCalls starts n calls to method through the interceptor and returns
+// once each has either been rejected or entered the handler, together with
+// the function completing the admitted ones and the rejected count.
+func holdCalls(t *testing.T, interceptor grpc.UnaryServerInterceptor, method string, n int) (func(), int) {
+	t.Helper()
+
+	release := make(chan struct{})
+	admitted := make(chan struct{}, n)
+	rejected := make(chan error, n)
+	handler := func(context.Context, any) (any, error) {
+		admitted <- struct{}{}
+		<-release
+
+		return &calculator.Response{}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info := &grpc.UnaryServerInfo{FullMethod: method}
+			if _, err := interceptor(context.Background(), &calculator.Request{}, info, handler); err != nil {
+				rejected <- err
+			}
+		}()
+	}
+
+	count := 0
+	for range n {
+		select {
+		case <-admitted:
+		case err := <-rejected:
+			if code := status.Code(err); code != codes.ResourceExhausted {
+				t.Errorf("rejected call: code = %s, want %s", code, codes.ResourceExhausted)
+			}
+			count++
+		}
+	}
+
+	return func() {
+		close(release)
+		wg.Wait()
+	}, count
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	cases := []struct {
+		name     string
+		max      int
+		method   string
+		calls    int
+		rejected int
+	}{
+		{name: "within the limit", max: 3, method: calculator.Calculator_Add_FullMethodName, calls: 3},
+		{name: "beyond the limit", max: 2, method: calculator.Calculator_Add_FullMethodName, calls: 5, rejected: 3},
+		{name: "unlimited", max: 0, method: calculator.Calculator_Add_FullMethodName, calls: 10},
+		{name: "other services are not limited", max: 1, method: "/admin.Admin/Status", calls: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			done, rejected := holdCalls(t, UnaryServerInterceptor(NewLimiter(tc.max)), tc.method, tc.calls)
+			done()
+			if rejected != tc.rejected {
+				t.Errorf("rejected %d calls, want %d", rejected, tc.rejected)
+			}
+		})
+	}
+}
+
+func TestLimiterRelease(t *testing.T) {
+	l := NewLimiter(1)
+	interceptor := UnaryServerInterceptor(l)
+
+	done, rejected := holdCalls(t, interceptor, calculator.Calculator_Add_FullMethodName, 2)
+	done()
+	if rejected != 1 {
+		t.Fatalf("rejected %d calls, want 1", rejected)
+	}
+
+	// Completed calls free their slot, failed ones included.
+	info := &grpc.UnaryServerInfo{FullMethod: calculator.Calculator_Add_FullMethodName}
+	failing := func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "division by zero")
+	}
+	if _, err := interceptor(context.Background(), &calculator.Request{}, info, failing); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("failed call: error = %v", err)
+	}
+	if l.inflight.Load() != 0 {
+		t.Errorf("inflight = %d, want 0", l.inflight.Load())
+	}
+}
+
+func TestLimiterSetMax(t *testing.T) {
+	l := NewLimiter(1)
+	interceptor := UnaryServerInterceptor(l)
+
+	l.SetMax(3)
+	done, rejected := holdCalls(t, interceptor, calculator.Calculator_Add_FullMethodName, 4)
+	if rejected != 1 {
+		t.Errorf("raised limit: rejected %d calls, want 1", rejected)
+	}
+
+	// Lowering the limit keeps the calls in flight and rejects new ones.
+	l.SetMax(1)
+	if _, err := l.acquire(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("lowered limit: error = %v, want %s", err, codes.ResourceExhausted)
+	}
+	done()
+	if l.inflight.Load() != 0 {
+		t.Errorf("inflight = %d, want 0", l.inflight.Load())
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/internal/audit"
+	"github.com/rodneyosodo/gophercon/internal/redact"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ calculator.Service = (*auditing)(nil)
+
+type auditing struct {
+	sink       audit.Sink
+	redactor   *redact.Redactor
+	failClosed bool
+	logger     *slog.Logger
+	svc        calculator.Service
+}
+
+// Audit appends a record of every call to sink. It must be wrapped by
+// Tracing for the records to carry the trace ID. Recorded values are subject
+// to redactor. Failures to write a record are logged to logger and, when
+// failClosed is set, fail the call with Unavailable so that no calculation
+// goes unaudited.
+func Audit(sink audit.Sink, redactor *redact.Redactor, failClosed bool, logger *slog.Logger, svc calculator.Service) calculator.Service {
+	return &auditing{sink, redactor, failClosed, logger, svc}
+}
+
+func (a *auditing) Add(ctx context.Context, x, y int64) (int64, error) {
+	return a.audit(ctx, "Add", x, y, a.svc.Add)
+}
+
+func (a *auditing) Subtract(ctx context.Context, x, y int64) (int64, error) {
+	return a.audit(ctx, "Subtract", x, y, a.svc.Subtract)
+}
+
+func (a *auditing) Multiply(ctx context.Context, x, y int64) (int64, error) {
+	return a.audit(ctx, "Multiply", x, y, a.svc.Multiply)
+}
+
+func (a *auditing) Divide(ctx context.Context, x, y int64) (int64, error) {
+	return a.audit(ctx, "Divide", x, y, a.svc.Divide)
+}
+
+func (a *auditing) audit(ctx context.Context, operation string, x, y int64, call func(context.Context, int64, int64) (int64, error)) (int64, error) {
+	result, err := call(ctx, x, y)
+
+	record := audit.Record{
+		Time:      time.Now().UTC(),
+		Operation: operation,
+		Status:    code(err).String(),
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		record.Principal = a.redact("principal", p.Subject)
+	}
+	record.A = a.redact("a", strconv.FormatInt(x, 10))
+	record.B = a.redact("b", strconv.FormatInt(y, 10))
+	if err == nil {
+		record.Result = a.redact("result", strconv.FormatInt(result, 10))
+	} else {
+		record.Error = a.redact("error", err.Error())
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		record.TraceID = sc.TraceID().String()
+	}
+
+	// The request context may be cancelled already; the record is written
+	// regardless.
+	if werr := a.sink.Write(context.WithoutCancel(ctx), record); werr != nil {
+		a.logger.ErrorContext(ctx, "Failed to write audit record",
+			slog.String("operation", operation),
+			slog.String("error", werr.Error()),
+		)
+		if a.failClosed {
+			return 0, status.Error(codes.Unavailable, "failed to write audit record")
+		}
+	}
+
+	return result, err
+}
+
+// redact returns the value of field as the policy allows, empty when it is
+// dropped.
+func (a *auditing) redact(field, value string) string {
+	value, ok := a.redactor.String(field, value)
+	if !ok {
+		return ""
+	}
+
+	return value
+}
+
+// code returns the gRPC status code the call ends with.
+func code(err error) codes.Code {
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+
+	return status.FromContextError(err).Code()
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/calculator/history"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ calculator.Service = (*recording)(nil)
+
+type recording struct {
+	store  history.Store
+	logger *slog.Logger
+	svc    calculator.Service
+}
+
+// History saves every completed call to store, failed ones included.
+// Calculations are saved as is so that they can be scoped to their principal
+// and replayed; the redaction policy applies when the history RPCs return
+// them. It must be wrapped by Tracing for the calculations to carry the trace
+// ID. Calls are not failed when the calculation cannot be saved; the error is
+// logged to logger instead.
+func History(store history.Store, logger *slog.Logger, svc calculator.Service) calculator.Service {
+	return &recording{store, logger, svc}
+}
+
+func (r *recording) Add(ctx context.Context, a, b int64) (int64, error) {
+	return r.record(ctx, "Add", a, b, r.svc.Add)
+}
+
+func (r *recording) Subtract(ctx context.Context, a, b int64) (int64, error) {
+	return r.record(ctx, "Subtract", a, b, r.svc.Subtract)
+}
+
+func (r *recording) Multiply(ctx context.Context, a, b int64) (int64, error) {
+	return r.record(ctx, "Multiply", a, b, r.svc.Multiply)
+}
+
+func (r *recording) Divide(ctx context.Context, a, b int64) (int64, error) {
+	return r.record(ctx, "Divide", a, b, r.svc.Divide)
+}
+
+func (r *recording) record(ctx context.Context, operation string, a, b int64, call func(context.Context, int64, int64) (int64, error)) (int64, error) {
+	begin := time.Now()
+	result, err := call(ctx, a, b)
+
+	c := history.Calculation{
+		Time:      begin.UTC(),
+		Operation: operation,
+		A:         a,
+		B:         b,
+		Result:    result,
+		Status:    code(err).String(),
+		Duration:  time.Since(begin),
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		c.Principal = p.Subject
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		c.TraceID = sc.TraceID().String()
+	}
+
+	if _, serr := r.store.Save(context.WithoutCancel(ctx), c); serr != nil {
+		r.logger.ErrorContext(ctx, "Failed to save calculation",
+			slog.String("operation", operation),
+			slog.String("error", serr.Error()),
+		)
+	}
+
+	return result, err
+}
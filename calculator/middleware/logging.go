@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
 )
 
 var _ calculator.Service = (*logging)(nil)
@@ -29,6 +30,9 @@ func (l *logging) Add(ctx context.Context, a, b int64) (result int64, err error)
 			slog.Int64("a", a),
 			slog.Int64("b", b),
 		}
+		if p, ok := auth.PrincipalFromContext(ctx); ok {
+			args = append(args, slog.String("principal", p.Subject))
+		}
 
 		switch err {
 		case nil:
@@ -49,6 +53,9 @@ func (l *logging) Subtract(ctx context.Context, a, b int64) (result int64, err e
 			slog.Int64("a", a),
 			slog.Int64("b", b),
 		}
+		if p, ok := auth.PrincipalFromContext(ctx); ok {
+			args = append(args, slog.String("principal", p.Subject))
+		}
 
 		switch err {
 		case nil:
@@ -69,6 +76,9 @@ func (l *logging) Multiply(ctx context.Context, a, b int64) (result int64, err e
 			slog.Int64("a", a),
 			slog.Int64("b", b),
 		}
+		if p, ok := auth.PrincipalFromContext(ctx); ok {
+			args = append(args, slog.String("principal", p.Subject))
+		}
 
 		switch err {
 		case nil:
@@ -89,6 +99,9 @@ func (l *logging) Divide(ctx context.Context, a, b int64) (result int64, err err
 			slog.Int64("a", a),
 			slog.Int64("b", b),
 		}
+		if p, ok := auth.PrincipalFromContext(ctx); ok {
+			args = append(args, slog.String("principal", p.Subject))
+		}
 
 		switch err {
 		case nil:
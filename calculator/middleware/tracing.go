@@ -2,57 +2,94 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"runtime/pprof"
+	"strings"
 
 	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/internal/redact"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// profileIDKey is the span attribute Grafana looks for to link a span to the
+// Pyroscope profiles labelled with its span_id.
+const profileIDKey = "pyroscope.profile.id"
+
 var _ calculator.Service = (*tracing)(nil)
 
 type tracing struct {
-	tracer trace.Tracer
-	svc    calculator.Service
+	tracer   trace.Tracer
+	redactor *redact.Redactor
+	svc      calculator.Service
 }
 
-func Tracing(tracer trace.Tracer, svc calculator.Service) calculator.Service {
-	return &tracing{tracer, svc}
+// Tracing wraps every call in a span. The call runs with the pprof labels
+// "operation" and, when the span is sampled, "span_id", so that the CPU
+// samples Pyroscope collects during the call can be found from the span.
+// Allocation profiles carry no labels, so only CPU profiles can be linked.
+// Span attributes and the error description are subject to redactor.
+func Tracing(tracer trace.Tracer, redactor *redact.Redactor, svc calculator.Service) calculator.Service {
+	return &tracing{tracer, redactor, svc}
 }
 
-func (t *tracing) Add(ctx context.Context, a, b int64) (result int64, err error) {
-	defer t.trace(ctx, "calculator.Add", a, b, result, err)
-
-	return t.svc.Add(ctx, a, b)
+func (t *tracing) Add(ctx context.Context, a, b int64) (int64, error) {
+	return t.trace(ctx, "calculator.Add", a, b, t.svc.Add)
 }
 
-func (t *tracing) Subtract(ctx context.Context, a, b int64) (result int64, err error) {
-	defer t.trace(ctx, "calculator.Subtract", a, b, result, err)
-
-	return t.svc.Subtract(ctx, a, b)
+func (t *tracing) Subtract(ctx context.Context, a, b int64) (int64, error) {
+	return t.trace(ctx, "calculator.Subtract", a, b, t.svc.Subtract)
 }
 
-func (t *tracing) Multiply(ctx context.Context, a, b int64) (result int64, err error) {
-	defer t.trace(ctx, "calculator.Multiply", a, b, result, err)
-
-	return t.svc.Multiply(ctx, a, b)
+func (t *tracing) Multiply(ctx context.Context, a, b int64) (int64, error) {
+	return t.trace(ctx, "calculator.Multiply", a, b, t.svc.Multiply)
 }
 
-func (t *tracing) Divide(ctx context.Context, a, b int64) (result int64, err error) {
-	defer t.trace(ctx, "calculator.Divide", a, b, result, err)
-
-	return t.svc.Divide(ctx, a, b)
+func (t *tracing) Divide(ctx context.Context, a, b int64) (int64, error) {
+	return t.trace(ctx, "calculator.Divide", a, b, t.svc.Divide)
 }
 
-func (t *tracing) trace(ctx context.Context, name string, a, b, result int64, err error) {
+func (t *tracing) trace(ctx context.Context, name string, a, b int64, call func(context.Context, int64, int64) (int64, error)) (result int64, err error) {
 	attributes := []attribute.KeyValue{
 		attribute.Int64("a", a),
 		attribute.Int64("b", b),
-		attribute.Int64("result", result),
 	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		attributes = append(attributes, attribute.String("principal", p.Subject))
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(t.redactor.KeyValues(attributes...)...))
+	defer span.End()
+
+	labels := []string{"operation", strings.TrimPrefix(name, "calculator.")}
+	if sc := span.SpanContext(); sc.IsSampled() {
+		spanID := sc.SpanID().String()
+		labels = append(labels, "span_id", spanID)
+		span.SetAttributes(attribute.String(profileIDKey, spanID))
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+		result, err = call(ctx, a, b)
+	})
+
+	span.SetAttributes(t.redactor.KeyValues(attribute.Int64("result", result))...)
 	if err != nil {
-		attributes = append(attributes, attribute.String("error", err.Error()))
+		// The error message is recorded only as the policy allows, on the
+		// attribute, the exception event and the status.
+		description, ok := t.redactor.String("error", err.Error())
+		switch {
+		case !ok:
+			description = ""
+		case description == err.Error():
+			span.RecordError(err)
+		default:
+			span.RecordError(errors.New(description))
+		}
+		if ok {
+			span.SetAttributes(attribute.String("error", description))
+		}
+		span.SetStatus(codes.Error, description)
 	}
-	_, span := t.tracer.Start(ctx, name, trace.WithAttributes(attributes...))
 
-	span.End()
+	return result, err
 }
@@ -0,0 +1,9 @@
+package calculator
+
+import _ "embed"
+
+// OpenAPISpec is the OpenAPI v2 description of the REST/JSON gateway,
+// generated from the HTTP annotations in calculator.proto.
+//
+//go:embed calculator.swagger.json
+var OpenAPISpec []byte
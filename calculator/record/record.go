@@ -0,0 +1,189 @@
+// Package record captures calculator calls so that they can be replayed
+// against another server, e.g. to reproduce a production incident locally.
+//
+// Each call is written as one JSON line holding the method, the incoming
+// metadata without credentials, the request, the response or status, the
+// deadline the caller set and the time the call took.
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const fileMode = 0o600
+
+// Methods are the recorded methods: the operations of the Calculator service.
+var Methods = []string{
+	calculator.Calculator_Add_FullMethodName,
+	calculator.Calculator_Subtract_FullMethodName,
+	calculator.Calculator_Multiply_FullMethodName,
+	calculator.Calculator_Divide_FullMethodName,
+}
+
+// gatewayPrefix is prepended by the REST gateway to the HTTP headers it
+// forwards, e.g. grpcgateway-authorization.
+const gatewayPrefix = "grpcgateway-"
+
+// credentialKeys are the metadata keys never recorded, with or without
+// gatewayPrefix.
+var credentialKeys = []string{"authorization", "x-api-key", "cookie"}
+
+// Credential reports whether the metadata key carries credentials.
+func Credential(key string) bool {
+	return slices.Contains(credentialKeys, strings.TrimPrefix(strings.ToLower(key), gatewayPrefix))
+}
+
+// Entry is one recorded call.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	// Metadata is the incoming metadata without credentials.
+	Metadata map[string][]string `json:"metadata,omitempty"`
+	// Timeout is the time left before the caller's deadline, in nanoseconds.
+	// Zero means no deadline.
+	Timeout  time.Duration   `json:"timeout,omitempty"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// Code is the gRPC status code name, e.g. OK.
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	// Duration is the time the server took, in nanoseconds.
+	Duration time.Duration `json:"duration"`
+}
+
+// Recorder appends entries to a file.
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewRecorder appends to the file at path, creating it if needed.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+
+	return &Recorder{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Write appends e.
+func (r *Recorder) Write(e Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// UnaryServerInterceptor records the calls to Methods. It should be the
+// first interceptor so that calls rejected by authentication, authorization
+// or limits are recorded too. Calls are never failed because of recording;
+// errors are passed to onError.
+func UnaryServerInterceptor(r *Recorder, onError func(error)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(Methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		e := Entry{Time: time.Now().UTC(), Method: info.FullMethod}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			e.Metadata = metadata.MD{}
+			for key, values := range md {
+				if !Credential(key) {
+					e.Metadata[key] = slices.Clone(values)
+				}
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			e.Timeout = time.Until(deadline)
+		}
+
+		begin := time.Now()
+		resp, err := handler(ctx, req)
+		e.Duration = time.Since(begin)
+
+		s := status.Convert(err)
+		e.Code, e.Message = s.Code().String(), s.Message()
+
+		var merr error
+		if e.Request, merr = marshal(req); merr == nil && err == nil {
+			e.Response, merr = marshal(resp)
+		}
+		if merr == nil {
+			merr = r.Write(e)
+		}
+		if merr != nil {
+			onError(merr)
+		}
+
+		return resp, err
+	}
+}
+
+// marshal encodes a message as compact JSON. protojson randomises its
+// whitespace, which would otherwise make identical messages differ.
+func marshal(v any) (json.RawMessage, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to record %T: not a protobuf message", v)
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record %T: %w", v, err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to record %T: %w", v, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Read decodes the entries of a recording in order.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20) //nolint:mnd // entries larger than 1 MiB are not expected
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
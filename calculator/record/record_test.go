@@ -0,0 +1,224 @@
+package record
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// recordCall records one call to method with md, answered by handler, and
+// returns the entries of the recording.
+func recordCall(t *testing.T, method string, md metadata.MD, handler grpc.UnaryHandler) []Entry {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	r, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	interceptor := UnaryServerInterceptor(r, func(err error) { t.Errorf("onError(%v)", err) })
+
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	req := &calculator.Request{A: 6, B: 3}
+	// The handler's error is returned as is and recorded; it is checked
+	// through the entry.
+	_, _ = interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+	entries, err := Read(file)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	return entries
+}
+
+func divide(_ context.Context, req any) (any, error) {
+	r := req.(*calculator.Request)
+
+	return &calculator.Response{Result: r.GetA() / r.GetB()}, nil
+}
+
+func TestCredential(t *testing.T) {
+	cases := []struct {
+		key  string
+		want bool
+	}{
+		{key: "authorization", want: true},
+		{key: "x-api-key", want: true},
+		{key: "cookie", want: true},
+		{key: "grpcgateway-authorization", want: true},
+		{key: "grpcgateway-cookie", want: true},
+		{key: "Grpcgateway-Authorization", want: true},
+		{key: "grpcgateway-user-agent", want: false},
+		{key: "idempotency-key", want: false},
+		{key: "x-request-id", want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.key, func(t *testing.T) {
+			if got := Credential(tc.key); got != tc.want {
+				t.Errorf("Credential(%q) = %v, want %v", tc.key, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorStripsCredentials(t *testing.T) {
+	cases := []struct {
+		name string
+		md   metadata.MD
+		want map[string]string
+	}{
+		{
+			name: "grpc credentials",
+			md:   metadata.Pairs("authorization", "Bearer token", "x-api-key", "secret", "x-request-id", "1"),
+			want: map[string]string{"x-request-id": "1"},
+		},
+		{
+			name: "gateway forwarded credentials",
+			md: metadata.Pairs(
+				"grpcgateway-authorization", "Bearer token",
+				"grpcgateway-cookie", "session=secret",
+				"grpcgateway-user-agent", "curl",
+			),
+			want: map[string]string{"grpcgateway-user-agent": "curl"},
+		},
+		{
+			name: "no credentials",
+			md:   metadata.Pairs("idempotency-key", "k"),
+			want: map[string]string{"idempotency-key": "k"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := recordCall(t, calculator.Calculator_Divide_FullMethodName, tc.md, divide)
+			if len(entries) != 1 {
+				t.Fatalf("recorded %d entries, want 1", len(entries))
+			}
+
+			got := entries[0].Metadata
+			if len(got) != len(tc.want) {
+				t.Errorf("Metadata = %v, want %v", got, tc.want)
+			}
+			for key, value := range tc.want {
+				if values := got[key]; len(values) != 1 || values[0] != value {
+					t.Errorf("Metadata[%q] = %v, want [%s]", key, values, value)
+				}
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorEntry(t *testing.T) {
+	failing := func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "division by zero")
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		handler  grpc.UnaryHandler
+		recorded bool
+		code     string
+		message  string
+		response string
+	}{
+		{
+			name:     "success",
+			method:   calculator.Calculator_Divide_FullMethodName,
+			handler:  divide,
+			recorded: true,
+			code:     "OK",
+			response: `{"result":"2"}`,
+		},
+		{
+			name:     "failure",
+			method:   calculator.Calculator_Divide_FullMethodName,
+			handler:  failing,
+			recorded: true,
+			code:     "InvalidArgument",
+			message:  "division by zero",
+		},
+		{
+			name:    "other method",
+			method:  calculator.Calculator_ListHistory_FullMethodName,
+			handler: divide,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := recordCall(t, tc.method, metadata.MD{}, tc.handler)
+			if !tc.recorded {
+				if len(entries) != 0 {
+					t.Errorf("recorded %d entries, want none", len(entries))
+				}
+
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("recorded %d entries, want 1", len(entries))
+			}
+
+			e := entries[0]
+			if e.Method != tc.method || e.Code != tc.code || e.Message != tc.message {
+				t.Errorf("entry = %s %s %q, want %s %s %q", e.Method, e.Code, e.Message, tc.method, tc.code, tc.message)
+			}
+			if string(e.Request) != `{"a":"6","b":"3"}` {
+				t.Errorf("Request = %s", e.Request)
+			}
+			if string(e.Response) != tc.response {
+				t.Errorf("Response = %s, want %s", e.Response, tc.response)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		entries int
+		wantErr string
+	}{
+		{name: "empty", input: ""},
+		{name: "entries and blank lines", input: "{\"method\":\"/a\"}\n\n{\"method\":\"/b\"}\n", entries: 2},
+		{name: "invalid line", input: "{\"method\":\"/a\"}\nnot json\n", wantErr: "line 2"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := Read(strings.NewReader(tc.input))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Read() error = %v, want %q", err, tc.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(entries) != tc.entries {
+				t.Errorf("Read() = %d entries, want %d", len(entries), tc.entries)
+			}
+		})
+	}
+}
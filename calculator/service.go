@@ -10,40 +10,33 @@ import (
 	"io"
 	"math/big"
 	"net/http"
-	"time"
 )
 
-const (
-	maxMemory            = 500 * 1024 * 1024 // 500 MB
-	largeFactorialNumber = 3e5
-	waitTime             = 5 * time.Second
-)
+const largeFactorialNumber = 3e5
 
 type service struct {
 	httpClient *http.Client
+	faults     *Faults
+	workloads  *Workloads
 }
 
-func NewService(httpClient *http.Client) Service {
+// NewService returns the calculator service. Every operation is subject to
+// the fault profile held by faults; Add and Subtract simulate the workload
+// held by workloads.
+func NewService(httpClient *http.Client, faults *Faults, workloads *Workloads) Service {
 	return &service{
 		httpClient: httpClient,
+		faults:     faults,
+		workloads:  workloads,
 	}
 }
 
 func (s *service) Add(ctx context.Context, a, b int64) (int64, error) {
-	largeSlice := make([]byte, maxMemory)
-
-	// This loop simulates holding onto memory without freeing it
-	for i := range largeSlice {
-		select {
-		case <-ctx.Done():
-
-			return 0, ctx.Err()
-		default:
-			// Simulate work by filling the slice with data
-			largeSlice[i] = byte(i)
-		}
+	workload := s.workloads.Workload()
+	if err := allocate(ctx, workload.AddMemory, workload.AddHold); err != nil {
+		return 0, err
 	}
-	if err := errorFunc(); err != nil {
+	if err := s.faults.inject(ctx); err != nil {
 		return 0, err
 	}
 
@@ -51,13 +44,10 @@ func (s *service) Add(ctx context.Context, a, b int64) (int64, error) {
 }
 
 func (s *service) Subtract(ctx context.Context, a, b int64) (int64, error) {
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	default:
-		time.Sleep(waitTime)
+	if err := wait(ctx, s.workloads.Workload().SubtractDelay); err != nil {
+		return 0, err
 	}
-	if err := errorFunc(); err != nil {
+	if err := s.faults.inject(ctx); err != nil {
 		return 0, err
 	}
 
@@ -65,27 +55,21 @@ func (s *service) Subtract(ctx context.Context, a, b int64) (int64, error) {
 }
 
 func (s *service) Multiply(ctx context.Context, a, b int64) (int64, error) {
-	ctx, cancel := context.WithTimeout(ctx, waitTime)
-	defer cancel()
-
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	default:
-		if err := s.makePizzaRequest(ctx); err != nil {
-			return 0, err
-		}
-
-		if err := errorFunc(); err != nil {
-			return 0, err
-		}
-
-		return a * b, nil
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
+	if err := s.makePizzaRequest(ctx); err != nil {
+		return 0, err
+	}
+	if err := s.faults.inject(ctx); err != nil {
+		return 0, err
+	}
+
+	return a * b, nil
 }
 
-func (s *service) Divide(_ context.Context, a, b int64) (int64, error) {
-	if err := errorFunc(); err != nil {
+func (s *service) Divide(ctx context.Context, a, b int64) (int64, error) {
+	if err := s.faults.inject(ctx); err != nil {
 		return 0, err
 	}
 
@@ -151,11 +135,3 @@ func generateRandomNumber(maxNumber int64) int64 {
 
 	return n.Int64()
 }
-
-func errorFunc() error {
-	if generateRandomNumber(10) > 7 { //nolint:gomnd,mnd // Using 7 as an arbitrary threshold for error generation
-		return errors.New("random error")
-	}
-
-	return nil
-}
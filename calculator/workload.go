@@ -0,0 +1,90 @@
+package calculator
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// fillChunk is the amount of memory Add fills between two checks of its
+// context.
+const fillChunk = 1 << 20 // 1 MiB
+
+// Workload is the simulated work of the operations, each demonstrating one
+// pathology: Add puts pressure on memory and Subtract is slow.
+type Workload struct {
+	// AddMemory is the number of bytes Add allocates and fills.
+	AddMemory int64
+	// AddHold is how long Add keeps the memory before answering.
+	AddHold time.Duration
+	// SubtractDelay is how long Subtract waits before answering.
+	SubtractDelay time.Duration
+}
+
+// Workloads holds the current workload. It is safe for concurrent use and
+// can be updated while calls are served.
+type Workloads struct {
+	workload atomic.Pointer[Workload]
+}
+
+// NewWorkloads returns Workloads simulating workload.
+func NewWorkloads(workload Workload) *Workloads {
+	w := &Workloads{}
+	w.Set(workload)
+
+	return w
+}
+
+// Set replaces the workload. Calls in flight keep the previous one.
+func (w *Workloads) Set(workload Workload) {
+	w.workload.Store(&workload)
+}
+
+// Workload returns the current workload.
+func (w *Workloads) Workload() Workload {
+	return *w.workload.Load()
+}
+
+// allocate fills size bytes of fresh memory, so that every page is
+// committed, and keeps it for hold. It stops as soon as ctx is done.
+func allocate(ctx context.Context, size int64, hold time.Duration) error {
+	memory := make([]byte, size)
+
+	// The first chunk is written byte by byte and copied over the rest,
+	// which is far cheaper than writing every byte.
+	pattern := memory[:min(size, fillChunk)]
+	for i := range pattern {
+		pattern[i] = byte(i)
+	}
+	for offset := int64(len(pattern)); offset < size; offset += fillChunk {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		copy(memory[offset:], pattern)
+	}
+
+	if err := wait(ctx, hold); err != nil {
+		return err
+	}
+	runtime.KeepAlive(memory)
+
+	return nil
+}
+
+// wait returns after d or as soon as ctx is done.
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
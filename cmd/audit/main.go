@@ -0,0 +1,36 @@
+// Command audit inspects the audit trail written by the Calculator server.
+//
+//	audit verify -dir /var/lib/gophercon/audit
+//
+// verify checks the hash chain across every file of the trail and reports
+// the first record that was edited, removed or reordered. It prints the hash
+// of the last record, which can be kept elsewhere to detect records removed
+// from the end of the trail later.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rodneyosodo/gophercon/internal/audit"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "verify" { //nolint:mnd // command and subcommand
+		fmt.Fprintln(os.Stderr, "usage: audit verify -dir <directory>")
+		os.Exit(2) //nolint:mnd // usage error
+	}
+
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := flags.String("dir", "audit", "audit trail directory")
+	_ = flags.Parse(os.Args[2:]) // exits on error
+
+	summary, err := audit.Verify(*dir)
+	if err != nil {
+		log.Fatalf("verification failed after %d records: %s", summary.Records, err.Error())
+	}
+
+	fmt.Printf("verified %d records in %d files, last hash %s\n", summary.Records, summary.Files, summary.LastHash)
+}
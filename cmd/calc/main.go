@@ -0,0 +1,326 @@
+// Command calc invokes operations on a Calculator server.
+//
+//	calc [flags] <add|subtract|multiply|divide> <a> <b>
+//	calc [flags] batch [file]
+//
+// Flags may appear before or after the operands. In batch mode every line of
+// the file, or of stdin when no file or "-" is given, holds one operation in
+// the form "<operation> <a> <b>"; blank lines and lines starting with '#' are
+// skipped. Every call runs in its own trace, whose ID is printed next to the
+// result so that it can be looked up in Tempo.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const usage = `Usage:
+  calc [flags] <add|subtract|multiply|divide> <a> <b>
+  calc [flags] batch [file]
+
+Flags:
+`
+
+var errFailedCalls = errors.New("one or more calls failed")
+
+type flags struct {
+	addr       string
+	apiKey     string
+	token      string
+	caCert     string
+	clientCert string
+	clientKey  string
+	timeout    time.Duration
+	output     string
+	otelURL    string
+}
+
+type operation func(calculator.Service, context.Context, int64, int64) (int64, error)
+
+var operations = map[string]operation{
+	"add":      calculator.Service.Add,
+	"subtract": calculator.Service.Subtract,
+	"multiply": calculator.Service.Multiply,
+	"divide":   calculator.Service.Divide,
+}
+
+func main() {
+	var f flags
+	fs := flag.NewFlagSet("calc", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&f.addr, "addr", "localhost:6000", "address of the Calculator server")
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("CALC_API_KEY"), "API key (defaults to $CALC_API_KEY)")
+	fs.StringVar(&f.token, "token", os.Getenv("CALC_TOKEN"), "JWT bearer token (defaults to $CALC_TOKEN)")
+	fs.StringVar(&f.caCert, "ca-cert", "", "CA certificate used to verify the server; enables TLS")
+	fs.StringVar(&f.clientCert, "cert", "", "client certificate for mTLS")
+	fs.StringVar(&f.clientKey, "key", "", "client key for mTLS")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "timeout of each call") //nolint:mnd // default call timeout
+	fs.StringVar(&f.output, "output", "text", "output format: text, json or csv")
+	fs.StringVar(&f.otelURL, "otel-url", os.Getenv("CALC_OTEL_URL"), "OTLP/HTTP endpoint to export traces to, e.g. http://localhost:4318")
+
+	args, err := parseArgs(fs, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2) //nolint:mnd // usage error
+	}
+
+	if err := run(context.Background(), f, args); err != nil {
+		if !errors.Is(err, errFailedCalls) {
+			fmt.Fprintln(os.Stderr, "calc:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, f flags, args []string) error {
+	out, err := newWriter(f.output, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader
+	switch args[0] {
+	case "batch":
+		input = os.Stdin
+		if len(args) > 1 && args[1] != "-" {
+			file, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+	default:
+		input = strings.NewReader(strings.Join(args, " "))
+	}
+
+	tp, err := newTracerProvider(ctx, f.otelURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tp.Shutdown(context.WithoutCancel(ctx)); err != nil {
+			fmt.Fprintln(os.Stderr, "calc: failed to flush traces:", err)
+		}
+	}()
+
+	c, err := client.New(clientOptions(f, tp)...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tracer := tp.Tracer("calc")
+	failed := false
+
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		res := call(ctx, tracer, c, text)
+		if res.Err != nil {
+			failed = true
+			if args[0] == "batch" {
+				res.Err = fmt.Errorf("line %d: %w", line, res.Err)
+			}
+		}
+		if err := out.Write(res); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	if failed {
+		return errFailedCalls
+	}
+
+	return nil
+}
+
+func call(ctx context.Context, tracer trace.Tracer, svc calculator.Service, text string) result {
+	res := result{}
+
+	fields := strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' })
+	if len(fields) != 3 { //nolint:mnd // operation and two operands
+		res.Err = fmt.Errorf("expected \"<operation> <a> <b>\", got %q", text)
+
+		return res
+	}
+	res.Operation = strings.ToLower(fields[0])
+
+	op, ok := operations[res.Operation]
+	if !ok {
+		res.Err = fmt.Errorf("unknown operation %q", fields[0])
+
+		return res
+	}
+
+	var err error
+	if res.A, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+		res.Err = fmt.Errorf("invalid operand %q: %w", fields[1], err)
+
+		return res
+	}
+	if res.B, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		res.Err = fmt.Errorf("invalid operand %q: %w", fields[2], err)
+
+		return res
+	}
+
+	ctx, span := tracer.Start(ctx, "calc "+res.Operation, trace.WithAttributes(
+		attribute.Int64("a", res.A),
+		attribute.Int64("b", res.B),
+	))
+	defer span.End()
+	res.TraceID = span.SpanContext().TraceID().String()
+
+	start := time.Now()
+	res.Result, res.Err = op(svc, ctx, res.A, res.B)
+	res.Duration = time.Since(start)
+
+	if res.Err != nil {
+		span.SetStatus(codes.Error, res.Err.Error())
+	}
+
+	return res
+}
+
+func clientOptions(f flags, tp trace.TracerProvider) []client.Option {
+	opts := []client.Option{
+		client.WithAddress(f.addr),
+		client.WithTimeout(f.timeout),
+		client.WithTracerProvider(tp),
+	}
+
+	switch {
+	case f.token != "":
+		opts = append(opts, client.WithBearerToken(f.token))
+	case f.apiKey != "":
+		opts = append(opts, client.WithAPIKey(f.apiKey))
+	}
+
+	if f.caCert != "" {
+		opts = append(opts, client.WithTLSFiles(f.caCert, f.clientCert, f.clientKey))
+	}
+
+	return opts
+}
+
+// newTracerProvider always samples so that every call gets a trace ID to
+// print. Spans are only exported when otelURL is set.
+func newTracerProvider(ctx context.Context, otelURL string) (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("calc"))),
+	}
+
+	if otelURL != "" {
+		u, err := url.Parse(otelURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otel url: %w", err)
+		}
+		exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+		if u.Path != "" {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithURLPath(u.Path))
+		}
+		if u.Scheme == "http" {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithSyncer(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(options...), nil
+}
+
+// parseArgs parses flags wherever they appear among the positional
+// arguments. Negative numbers are treated as operands, not flags.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var flagArgs, positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || isNumber(arg) {
+			positional = append(positional, arg)
+
+			continue
+		}
+
+		flagArgs = append(flagArgs, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		// Unknown flags take no value; fs.Parse reports them.
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+
+	if err := fs.Parse(flagArgs); err != nil {
+		return nil, err
+	}
+
+	return positional, nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+
+	return err == nil
+}
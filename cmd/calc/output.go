@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type result struct {
+	Operation string
+	A         int64
+	B         int64
+	Result    int64
+	Err       error
+	TraceID   string
+	Duration  time.Duration
+}
+
+type writer interface {
+	Write(res result) error
+	Flush() error
+}
+
+func newWriter(format string, w io.Writer) (writer, error) {
+	switch format {
+	case "text":
+		return &textWriter{w: w}, nil
+	case "json":
+		return &jsonWriter{encoder: json.NewEncoder(w)}, nil
+	case "csv":
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Write(res result) error {
+	var err error
+	switch {
+	case res.Err != nil && res.TraceID == "":
+		_, err = fmt.Fprintf(t.w, "error: %s\n", res.Err)
+	case res.Err != nil:
+		_, err = fmt.Fprintf(t.w, "%s %d %d: error: %s (trace_id=%s)\n", res.Operation, res.A, res.B, res.Err, res.TraceID)
+	default:
+		_, err = fmt.Fprintf(t.w, "%s %d %d = %d (trace_id=%s, duration=%s)\n", res.Operation, res.A, res.B, res.Result, res.TraceID, res.Duration)
+	}
+
+	return err
+}
+
+func (t *textWriter) Flush() error {
+	return nil
+}
+
+type jsonWriter struct {
+	encoder *json.Encoder
+}
+
+type jsonResult struct {
+	Operation string  `json:"operation,omitempty"`
+	A         int64   `json:"a"`
+	B         int64   `json:"b"`
+	Result    *int64  `json:"result,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	TraceID   string  `json:"trace_id,omitempty"`
+	Duration  float64 `json:"duration_seconds"`
+}
+
+func (j *jsonWriter) Write(res result) error {
+	out := jsonResult{
+		Operation: res.Operation,
+		A:         res.A,
+		B:         res.B,
+		TraceID:   res.TraceID,
+		Duration:  res.Duration.Seconds(),
+	}
+	if res.Err != nil {
+		out.Error = res.Err.Error()
+	} else {
+		out.Result = &res.Result
+	}
+
+	return j.encoder.Encode(out)
+}
+
+func (j *jsonWriter) Flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) Write(res result) error {
+	if !c.headerWritten {
+		if err := c.w.Write([]string{"operation", "a", "b", "result", "error", "trace_id", "duration_seconds"}); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	record := []string{
+		res.Operation,
+		strconv.FormatInt(res.A, 10),
+		strconv.FormatInt(res.B, 10),
+		"",
+		"",
+		res.TraceID,
+		strconv.FormatFloat(res.Duration.Seconds(), 'f', -1, 64),
+	}
+	if res.Err != nil {
+		record[4] = res.Err.Error()
+	} else {
+		record[3] = strconv.FormatInt(res.Result, 10)
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
@@ -0,0 +1,36 @@
+// Command dashboards writes the Grafana dashboards of the Calculator service
+// as JSON files, one per dashboard UID, for Grafana to provision.
+//
+//	dashboards -out grafana/dashboards
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/rodneyosodo/gophercon/internal/dashboards"
+)
+
+func main() {
+	out := flag.String("out", "grafana/dashboards", "directory to write the dashboards to")
+	flag.Parse()
+
+	all, err := dashboards.All()
+	if err != nil {
+		log.Fatalf("failed to build dashboards: %s", err.Error())
+	}
+
+	for _, d := range all {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			log.Fatalf("failed to marshal dashboard: %s", err.Error())
+		}
+		file := filepath.Join(*out, *d.Uid+".json")
+		if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil { //nolint:gosec // dashboards are read by Grafana
+			log.Fatalf("failed to write dashboard: %s", err.Error())
+		}
+	}
+}
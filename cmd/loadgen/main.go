@@ -0,0 +1,179 @@
+// Command loadgen drives load against a Calculator server and prints latency
+// percentiles and an error breakdown when done.
+//
+//	loadgen -model open -rate 200 -duration 1m -mix add=1,divide=3
+//	loadgen -model closed -vus 100 -think-time 1s -duration 30s -reuse-conn=false
+//	loadgen -scenario scenarios/smoke.yaml
+//
+// With -scenario, the load is described by a YAML file of stages and the
+// command exits with status 1 when a threshold is not met.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/client"
+	"github.com/rodneyosodo/gophercon/internal/loadgen"
+)
+
+type flags struct {
+	addr       string
+	apiKey     string
+	token      string
+	caCert     string
+	clientCert string
+	clientKey  string
+	poolSize   int
+	mix        string
+	a          string
+	b          string
+	model      string
+	rate       float64
+	maxFlight  int
+	vus        int
+	thinkTime  time.Duration
+	duration   time.Duration
+	timeout    time.Duration
+	reuseConn  bool
+	scenario   string
+}
+
+func main() {
+	var f flags
+	flag.StringVar(&f.addr, "addr", "localhost:6000", "address of the Calculator server")
+	flag.StringVar(&f.apiKey, "api-key", os.Getenv("LOADGEN_API_KEY"), "API key (defaults to $LOADGEN_API_KEY)")
+	flag.StringVar(&f.token, "token", os.Getenv("LOADGEN_TOKEN"), "JWT bearer token (defaults to $LOADGEN_TOKEN)")
+	flag.StringVar(&f.caCert, "ca-cert", "", "CA certificate used to verify the server; enables TLS")
+	flag.StringVar(&f.clientCert, "cert", "", "client certificate for mTLS")
+	flag.StringVar(&f.clientKey, "key", "", "client key for mTLS")
+	flag.IntVar(&f.poolSize, "conns", 1, "number of pooled connections when reusing connections")
+	flag.StringVar(&f.mix, "mix", "add=1,subtract=1,multiply=1,divide=1", "operation weights")
+	flag.StringVar(&f.a, "a", "const:18", "distribution of the first operand: const:<v>, uniform:<min>,<max> or normal:<mean>,<stddev>")
+	flag.StringVar(&f.b, "b", "const:3", "distribution of the second operand")
+	flag.StringVar(&f.model, "model", "closed", "arrival model: open (fixed rate) or closed (fixed virtual users)")
+	flag.Float64Var(&f.rate, "rate", 100, "arrivals per second of the open model")                 //nolint:mnd // default rate
+	flag.IntVar(&f.maxFlight, "max-in-flight", 1000, "maximum concurrent calls of the open model") //nolint:mnd // default bound
+	flag.IntVar(&f.vus, "vus", 100, "virtual users of the closed model")                           //nolint:mnd // same as k6.js
+	flag.DurationVar(&f.thinkTime, "think-time", time.Second, "pause of a virtual user between calls")
+	flag.DurationVar(&f.duration, "duration", 30*time.Second, "duration of the test") //nolint:mnd // same as k6.js
+	flag.DurationVar(&f.timeout, "timeout", 10*time.Second, "timeout of each call")   //nolint:mnd // default call timeout
+	flag.BoolVar(&f.reuseConn, "reuse-conn", true, "share connections between calls instead of connecting for every call")
+	flag.StringVar(&f.scenario, "scenario", "", "YAML scenario file; overrides the load flags")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if f.scenario != "" {
+		if !runScenario(ctx, f) {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	cfg, err := loadConfig(f)
+	if err != nil {
+		log.Fatalf("invalid configuration: %s", err.Error())
+	}
+
+	runner := loadgen.NewRunner(connector(f))
+	defer runner.Close()
+
+	stats := loadgen.NewStats()
+	start := time.Now()
+	if err := runner.Run(ctx, cfg, stats); err != nil {
+		log.Fatalf("load test failed: %s", err.Error())
+	}
+	stats.SetElapsed(time.Since(start))
+
+	if err := stats.Report(os.Stdout); err != nil {
+		log.Fatalf("failed to write report: %s", err.Error())
+	}
+}
+
+func runScenario(ctx context.Context, f flags) bool {
+	scenario, err := loadgen.LoadScenario(f.scenario)
+	if err != nil {
+		log.Fatalf("invalid scenario: %s", err.Error())
+	}
+
+	runner := loadgen.NewRunner(connector(f))
+	defer runner.Close()
+
+	result, err := scenario.Run(ctx, runner)
+	if err != nil {
+		log.Fatalf("scenario failed: %s", err.Error())
+	}
+
+	if err := result.Report(os.Stdout); err != nil {
+		log.Fatalf("failed to write report: %s", err.Error())
+	}
+
+	return result.Passed()
+}
+
+func loadConfig(f flags) (loadgen.Config, error) {
+	mix, err := loadgen.ParseMix(f.mix)
+	if err != nil {
+		return loadgen.Config{}, err
+	}
+	a, err := loadgen.ParseDistribution(f.a)
+	if err != nil {
+		return loadgen.Config{}, err
+	}
+	b, err := loadgen.ParseDistribution(f.b)
+	if err != nil {
+		return loadgen.Config{}, err
+	}
+
+	cfg := loadgen.Config{
+		Mix:              mix,
+		A:                a,
+		B:                b,
+		Model:            loadgen.Model(f.model),
+		Duration:         f.duration,
+		Rate:             f.rate,
+		MaxInFlight:      f.maxFlight,
+		VUs:              f.vus,
+		ThinkTime:        f.thinkTime,
+		ReuseConnections: f.reuseConn,
+		Timeout:          f.timeout,
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func connector(f flags) loadgen.Connector {
+	opts := []client.Option{client.WithAddress(f.addr)}
+	if f.reuseConn {
+		opts = append(opts, client.WithPoolSize(f.poolSize))
+	}
+	switch {
+	case f.token != "":
+		opts = append(opts, client.WithBearerToken(f.token))
+	case f.apiKey != "":
+		opts = append(opts, client.WithAPIKey(f.apiKey))
+	}
+	if f.caCert != "" {
+		opts = append(opts, client.WithTLSFiles(f.caCert, f.clientCert, f.clientKey))
+	}
+
+	return func() (calculator.Service, io.Closer, error) {
+		c, err := client.New(opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect: %w", err)
+		}
+
+		return c, c, nil
+	}
+}
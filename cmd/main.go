@@ -2,25 +2,43 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"github.com/caarlos0/env/v11"
-	"github.com/grafana/loki-client-go/loki"
-	"github.com/grafana/pyroscope-go"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rodneyosodo/gophercon/admin"
 	"github.com/rodneyosodo/gophercon/calculator"
 	"github.com/rodneyosodo/gophercon/calculator/api"
+	"github.com/rodneyosodo/gophercon/calculator/auth"
+	"github.com/rodneyosodo/gophercon/calculator/authz"
+	"github.com/rodneyosodo/gophercon/calculator/deadline"
+	"github.com/rodneyosodo/gophercon/calculator/history"
+	"github.com/rodneyosodo/gophercon/calculator/idempotency"
+	"github.com/rodneyosodo/gophercon/calculator/limit"
 	"github.com/rodneyosodo/gophercon/calculator/middleware"
-	slogloki "github.com/samber/slog-loki/v3"
+	"github.com/rodneyosodo/gophercon/calculator/record"
+	"github.com/rodneyosodo/gophercon/internal/audit"
+	"github.com/rodneyosodo/gophercon/internal/certs"
+	"github.com/rodneyosodo/gophercon/internal/config"
+	"github.com/rodneyosodo/gophercon/internal/filewatch"
+	"github.com/rodneyosodo/gophercon/internal/loglevel"
+	"github.com/rodneyosodo/gophercon/internal/logsample"
+	"github.com/rodneyosodo/gophercon/internal/lokilog"
+	"github.com/rodneyosodo/gophercon/internal/redact"
+	"github.com/rodneyosodo/gophercon/internal/runtimemetrics"
+	"github.com/rodneyosodo/gophercon/internal/tracing"
 	slogmulti "github.com/samber/slog-multi"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
@@ -33,91 +51,96 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/stats/opentelemetry"
 )
 
-type config struct {
-	LogLevel           string        `env:"GOPHERCON_LOG_LEVEL"           envDefault:"info"`
-	Addr               string        `env:"GOPHERCON_ADDR"                envDefault:":6000"`
-	PrometheusEndpoint string        `env:"GOPHERCON_PROMETHEUS_ENDPOINT" envDefault:":6001"`
-	ReadTimeout        time.Duration `env:"GOPHERCON_READ_TIMEOUT"        envDefault:"10s"`
-	WriteTimeout       time.Duration `env:"GOPHERCON_WRITE_TIMEOUT"       envDefault:"10s"`
-	OTELURL            url.URL       `env:"GOPHERCON_OTEL_URL"            envDefault:""`
-	TraceRatio         float64       `env:"GOPHERCON_TRACE_RATIO"         envDefault:"0.1"`
-	LokiURL            string        `env:"GOPHERCON_LOKI_URL"            envDefault:""`
-	PyroScopeURL       string        `env:"GOPHERCON_PYROSCOPE_URL"       envDefault:""`
-}
-
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	g, ctx := errgroup.WithContext(ctx)
 
-	cfg := config{}
-	if err := env.Parse(&cfg); err != nil {
+	configFile := flag.String("config", os.Getenv("GOPHERCON_CONFIG_FILE"), "YAML or TOML configuration file, overridden by GOPHERCON_* environment variables")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
 		log.Fatalf("failed to load configuration : %s", err.Error())
 	}
+	if *printConfig {
+		data, err := cfg.Redacted().Marshal()
+		if err != nil {
+			log.Fatalf("failed to print configuration: %s", err.Error())
+		}
+		os.Stdout.Write(data) //nolint:errcheck // nothing to do on failure
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %s", err.Error())
+	}
+	if *printConfig {
+		return
+	}
 
-	var level slog.Level
-	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
-		log.Fatalf("failed to parse log level: %s", err.Error())
+	exporter, err := prometheus.New(prometheus.WithProducer(runtimemetrics.NewProducer()))
+	if err != nil {
+		log.Fatalf("Failed to start prometheus exporter: %s", err.Error())
 	}
+	provider := metric.NewMeterProvider(metric.WithReader(exporter))
+
+	// Handlers accept every level; levels filters per component and follows
+	// configuration reloads, the Admin service and SIGUSR1.
 	fanout := slogmulti.Fanout(
 		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: level,
+			Level: slog.LevelDebug,
 		}),
 	)
 	if cfg.LokiURL != "" {
-		config, err := loki.NewDefaultConfig(cfg.LokiURL)
-		if err != nil {
-			log.Fatalf("failed to create loki config: %s", err.Error())
-		}
-		config.TenantID = "gophercon"
-		client, err := loki.New(config)
+		sink, err := lokilog.New(cfg.LokiURL, lokilog.Config(cfg.Loki), provider)
 		if err != nil {
-			log.Fatalf("failed to create loki client: %s", err.Error())
+			log.Fatalf("failed to create loki sink: %s", err.Error())
 		}
+		defer sink.Stop()
 
-		hander := slogloki.Option{Level: level, Client: client}.NewLokiHandler()
 		fanout = slogmulti.Fanout(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level: level,
+				Level: slog.LevelDebug,
 			}),
-			hander,
+			sink.Handler(slog.LevelDebug),
 		)
 	}
 
-	logger := slog.New(fanout).With("service", "gophercon")
+	// Sampling thins out successful calls before they reach stdout and Loki.
+	sampler, err := logsample.NewSampler(logsample.Config(cfg.LogSampling), provider)
+	if err != nil {
+		log.Fatalf("failed to create log sampler: %s", err.Error())
+	}
+	levels := loglevel.New(sampler.Handler(fanout).WithAttrs([]slog.Attr{slog.String("service", "gophercon")}), cfg.Level())
+	logger := levels.Logger("")
 	slog.SetDefault(logger)
 
-	if cfg.PyroScopeURL != "" {
-		if _, err := pyroscope.Start(pyroscope.Config{
-			ApplicationName: "gophercon",
-			ServerAddress:   cfg.PyroScopeURL,
-			Logger:          nil,
-			ProfileTypes: []pyroscope.ProfileType{
-				pyroscope.ProfileCPU,
-				pyroscope.ProfileAllocObjects,
-				pyroscope.ProfileAllocSpace,
-				pyroscope.ProfileInuseObjects,
-				pyroscope.ProfileInuseSpace,
-				pyroscope.ProfileGoroutines,
-				pyroscope.ProfileMutexCount,
-			},
-		}); err != nil {
-			log.Fatalf("failed to start pyroscope: %s", err.Error())
-		}
+	profiler, err := admin.NewProfiler("gophercon", cfg.PyroScopeURL, cfg.ProfileTypes, cfg.BlockProfileRate, cfg.MutexProfileFraction)
+	if err != nil {
+		log.Fatalf("failed to start pyroscope: %s", err.Error())
 	}
+	defer profiler.Stop()
 
+	traceSampler := tracing.NewRatioSampler(cfg.TraceRatio)
 	var tp trace.TracerProvider
 	switch {
-	case cfg.OTELURL == (url.URL{}):
+	case cfg.OTELURL == "":
 		tp = noop.NewTracerProvider()
 	default:
-		sdktp, err := initTracer(ctx, cfg.OTELURL, cfg.TraceRatio)
+		otelURL, err := url.Parse(cfg.OTELURL)
+		if err != nil {
+			log.Fatalf("failed to parse opentelemetry url: %s", err.Error())
+		}
+		sdktp, err := initTracer(ctx, *otelURL, traceSampler)
 		if err != nil {
 			log.Fatalf("failed to initialize opentelemetry: %s", err.Error())
 		}
@@ -130,11 +153,29 @@ func main() {
 	}
 	tracer := tp.Tracer("gophercon")
 
-	exporter, err := prometheus.New()
-	if err != nil {
-		log.Fatalf("Failed to start prometheus exporter: %s", err.Error())
+	if err := runtimemetrics.StartRuntime(provider); err != nil {
+		log.Fatalf("failed to start runtime metrics: %s", err.Error())
+	}
+	if err := runtimemetrics.StartProcess(provider); err != nil {
+		logger.Warn("Process metrics disabled", slog.String("error", err.Error()))
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		reloader, err := certs.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile, levels.Logger("tls"))
+		if err != nil {
+			log.Fatalf("failed to load tls certificates: %s", err.Error())
+		}
+		g.Go(func() error {
+			reloader.Watch(ctx, filewatch.DefaultInterval)
+
+			return nil
+		})
+		// Every server gets its own clone, since http.Server modifies the
+		// config it is given when configuring HTTP/2.
+		tlsConfig = reloader.TLSConfig()
+		logger.Info("TLS enabled", slog.Bool("mtls", cfg.TLSClientCAFile != ""))
 	}
-	provider := metric.NewMeterProvider(metric.WithReader(exporter))
 
 	g.Go(func() error {
 		server := &http.Server{
@@ -142,6 +183,10 @@ func main() {
 			Handler:      promhttp.Handler(),
 			ReadTimeout:  cfg.ReadTimeout,
 			WriteTimeout: cfg.WriteTimeout,
+			TLSConfig:    tlsConfig.Clone(),
+		}
+		if tlsConfig != nil {
+			return server.ListenAndServeTLS("", "")
 		}
 
 		return server.ListenAndServe()
@@ -157,31 +202,319 @@ func main() {
 		log.Fatalf("Failed to listen: %s", err.Error())
 	}
 
-	server := grpc.NewServer(so, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	// The redaction policy applies to every record of a calculation: logs,
+	// spans, both audit trails and the history RPCs.
+	redactor := redact.New(redact.Policy(cfg.Redaction))
+
+	authenticators, err := newAuthenticators(cfg)
+	if err != nil {
+		log.Fatalf("failed to create authenticators: %s", err.Error())
+	}
+
+	opts := []grpc.ServerOption{so, grpc.StatsHandler(otelgrpc.NewServerHandler())}
+	if cfg.RecordingFile != "" {
+		recorder, err := record.NewRecorder(cfg.RecordingFile)
+		if err != nil {
+			log.Fatalf("failed to open recording: %s", err.Error())
+		}
+		defer recorder.Close()
+
+		// Recording comes first so that rejected calls are replayed too.
+		opts = append(opts, grpc.ChainUnaryInterceptor(record.UnaryServerInterceptor(recorder, func(err error) {
+			logger.Error("Failed to record call", slog.String("error", err.Error()))
+		})))
+		logger.Info("Recording calls", slog.String("file", cfg.RecordingFile))
+	}
+
+	deadlines, err := deadline.New(deadline.Config(cfg.Deadlines), provider)
+	if err != nil {
+		log.Fatalf("failed to create deadlines: %s", err.Error())
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(deadline.UnaryServerInterceptor(deadlines)))
+
+	if len(authenticators) > 0 {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(authenticators...)),
+			grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authenticators...)),
+		)
+		logger.Info("Authentication enabled", slog.Int("authenticators", len(authenticators)))
+	}
+	if cfg.AuthzPolicyFile != "" {
+		authzLogger := levels.Logger("authz")
+		authorizer, err := authz.NewAuthorizer(cfg.AuthzPolicyFile, authzLogger)
+		if err != nil {
+			log.Fatalf("failed to load authorization policy: %s", err.Error())
+		}
+		g.Go(func() error {
+			authorizer.Watch(ctx, filewatch.DefaultInterval)
+
+			return nil
+		})
+
+		audit := slog.New(redactor.Handler(authzLogger.With(slog.String("log_type", "audit")).Handler()))
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(authz.UnaryServerInterceptor(authorizer, audit)),
+			grpc.ChainStreamInterceptor(authz.StreamServerInterceptor(authorizer, audit)),
+		)
+		logger.Info("Authorization enabled", slog.String("policy", cfg.AuthzPolicyFile))
+	}
+
+	if cfg.Idempotency.TTL > 0 {
+		store := idempotency.NewMemoryStore(cfg.Idempotency.MaxKeys)
+		deduplicator, err := idempotency.NewDeduplicator(store, cfg.Idempotency.TTL, deadlines.Timeout, logger, provider)
+		if err != nil {
+			log.Fatalf("failed to create deduplicator: %s", err.Error())
+		}
+		// Deduplication comes before the limit so that repeated calls are
+		// answered even when the calculator is saturated.
+		opts = append(opts, grpc.ChainUnaryInterceptor(idempotency.UnaryServerInterceptor(deduplicator)))
+	}
+
+	limiter := limit.NewLimiter(cfg.Limits.MaxConcurrentCalls)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(limit.UnaryServerInterceptor(limiter)),
+		grpc.ChainStreamInterceptor(limit.StreamServerInterceptor(limiter)),
+	)
+
+	server := grpc.NewServer(opts...)
 	reflection.Register(server)
 
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 10
-	retryClient.Logger = logger
+	retryClient.Logger = levels.Logger("upstream")
+	// Every attempt to the upstream pizza API is traced and measured.
+	retryClient.HTTPClient.Transport = otelhttp.NewTransport(retryClient.HTTPClient.Transport,
+		otelhttp.WithTracerProvider(tp),
+		otelhttp.WithMeterProvider(provider),
+	)
 	httpClient := retryClient.StandardClient()
 
-	service := calculator.NewService(httpClient)
-	service = middleware.Logging(logger, service)
-	service = middleware.Tracing(tracer, service)
-	calculator.RegisterCalculatorServer(server, api.NewGrpcServer(service))
+	faults := calculator.NewFaults(calculator.FaultProfile(cfg.Faults))
+	workloads := calculator.NewWorkloads(calculator.Workload(cfg.Workload))
+	service := calculator.NewService(httpClient, faults, workloads)
+	service = middleware.Logging(slog.New(redactor.Handler(levels.Logger("calculator").Handler())), service)
+	if cfg.Audit.Dir != "" {
+		auditSink, err := audit.NewFileSink(cfg.Audit.Dir, cfg.Audit.MaxSize)
+		if err != nil {
+			log.Fatalf("failed to open audit trail: %s", err.Error())
+		}
+		defer auditSink.Close()
+
+		service = middleware.Audit(auditSink, redactor, cfg.Audit.FailClosed, levels.Logger("audit"), service)
+		logger.Info("Audit trail enabled", slog.String("dir", cfg.Audit.Dir))
+	}
+	var store history.Store
+	if cfg.History.Store == "sqlite" {
+		sqlite, err := history.NewSQLite(ctx, cfg.History.Path)
+		if err != nil {
+			log.Fatalf("failed to open history store: %s", err.Error())
+		}
+		defer sqlite.Close()
+
+		store = sqlite
+		service = middleware.History(store, levels.Logger("history"), service)
+		logger.Info("History enabled", slog.String("store", cfg.History.Store), slog.String("path", cfg.History.Path))
+	}
+	service = middleware.Tracing(tracer, redactor, service)
+	calculator.RegisterCalculatorServer(server, api.NewGrpcServer(service, store, redactor))
+
+	handler, err := api.NewHandler(server, tlsConfig == nil)
+	if err != nil {
+		log.Fatalf("failed to create handler: %s", err.Error())
+	}
 
 	g.Go(func() error {
-		return server.Serve(listener)
+		// gRPC, gRPC-Web and Connect share the listener. No write timeout
+		// is set since calls are bounded by their own gRPC deadlines.
+		httpServer := &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			TLSConfig:         tlsConfig.Clone(),
+		}
+		if tlsConfig != nil {
+			return httpServer.ServeTLS(listener, "", "")
+		}
+
+		return httpServer.Serve(listener)
 	})
 
 	logger.Info("Calculator server started", slog.String("address", cfg.Addr))
 
+	if cfg.GatewayAddr != "" {
+		// The gateway reaches the service through an in-process listener on
+		// the same gRPC server, so REST callers are authenticated,
+		// authorized and instrumented like gRPC callers.
+		inprocess := api.NewPipeListener()
+		g.Go(func() error {
+			return server.Serve(inprocess)
+		})
+
+		conn, err := grpc.NewClient("passthrough:///gateway",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return inprocess.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		)
+		if err != nil {
+			log.Fatalf("failed to connect gateway: %s", err.Error())
+		}
+		defer conn.Close()
+
+		gatewayHandler, err := api.NewGatewayHandler(ctx, conn)
+		if err != nil {
+			log.Fatalf("failed to create gateway: %s", err.Error())
+		}
+
+		g.Go(func() error {
+			gateway := &http.Server{
+				Addr:         cfg.GatewayAddr,
+				Handler:      gatewayHandler,
+				ReadTimeout:  cfg.ReadTimeout,
+				WriteTimeout: cfg.WriteTimeout,
+				TLSConfig:    tlsConfig.Clone(),
+			}
+			if tlsConfig != nil {
+				return gateway.ListenAndServeTLS("", "")
+			}
+
+			return gateway.ListenAndServe()
+		})
+		logger.Info("REST gateway started", slog.String("address", cfg.GatewayAddr))
+	}
+
+	if cfg.AdminAddr != "" {
+		if len(authenticators) == 0 {
+			log.Fatalf("failed to start admin server: admin_addr requires at least one API key or a JWKS")
+		}
+		// The Admin service has its own gRPC server, only reachable on the
+		// admin listener, so that it is never exposed next to the Calculator.
+		adminGrpc := grpc.NewServer(so, grpc.StatsHandler(otelgrpc.NewServerHandler()),
+			grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(authenticators...)),
+			grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authenticators...)),
+		)
+		admin.RegisterAdminServer(adminGrpc, admin.NewGrpcServer(profiler, levels))
+		adminHandler, err := newAdminHandler(adminGrpc, levels, authenticators, tlsConfig == nil)
+		if err != nil {
+			log.Fatalf("failed to create admin handler: %s", err.Error())
+		}
+
+		g.Go(func() error {
+			// No write timeout is set since CPU profiles and execution
+			// traces stream for as long as the caller asks.
+			adminServer := &http.Server{
+				Addr:              cfg.AdminAddr,
+				Handler:           adminHandler,
+				ReadHeaderTimeout: cfg.ReadTimeout,
+				TLSConfig:         tlsConfig.Clone(),
+			}
+			if tlsConfig != nil {
+				return adminServer.ListenAndServeTLS("", "")
+			}
+
+			return adminServer.ListenAndServe()
+		})
+		logger.Info("Admin server started", slog.String("address", cfg.AdminAddr))
+	}
+
+	if *configFile != "" {
+		// The global level is only reset when log_level itself changes, so
+		// that editing another field keeps a level set through the Admin
+		// service.
+		logLevel := cfg.LogLevel
+		g.Go(func() error {
+			config.Watch(ctx, *configFile, cfg, filewatch.DefaultInterval, levels.Logger("config"), func(next config.Config) {
+				if next.LogLevel != logLevel {
+					logLevel = next.LogLevel
+					levels.Set("", next.Level()) //nolint:errcheck // the global level always exists
+				}
+				traceSampler.SetRatio(next.TraceRatio)
+				sampler.Set(logsample.Config(next.LogSampling))
+				redactor.Set(redact.Policy(next.Redaction))
+				faults.Set(calculator.FaultProfile(next.Faults))
+				workloads.Set(calculator.Workload(next.Workload))
+				limiter.SetMax(next.Limits.MaxConcurrentCalls)
+				deadlines.Set(deadline.Config(next.Deadlines))
+			})
+
+			return nil
+		})
+		logger.Info("Watching configuration", slog.String("file", *configFile))
+	}
+
+	g.Go(func() error {
+		// SIGUSR1 switches to debug logging and back, for when the admin
+		// endpoints are not reachable.
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR1)
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-signals:
+				level := levels.ToggleDebug()
+				logger.Warn("Log level toggled", slog.String("level", level.String()))
+			}
+		}
+	})
+
 	if err := g.Wait(); err != nil {
 		log.Fatalf("Failed to serve: %s", err.Error())
 	}
 }
 
-func initTracer(ctx context.Context, otelURL url.URL, fraction float64) (*sdktrace.TracerProvider, error) {
+// newAdminHandler serves the Admin service of server over gRPC, gRPC-Web and
+// Connect next to the profiling and log level endpoints, which require the
+// admin scope.
+func newAdminHandler(server *grpc.Server, levels *loglevel.Levels, authenticators []auth.Authenticator, h2cEnabled bool) (http.Handler, error) {
+	rpc, err := api.NewHandler(server, false)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/"+admin.Admin_ServiceDesc.ServiceName+"/", rpc)
+	mux.Handle("/", auth.HTTPMiddleware(admin.NewHandler(levels), admin.Scope, authenticators...))
+	if h2cEnabled {
+		return h2c.NewHandler(mux, &http2.Server{}), nil
+	}
+
+	return mux, nil
+}
+
+func newAuthenticators(cfg config.Config) ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+
+	keys := cfg.APIKeys
+	if cfg.APIKeysFile != "" {
+		fileKeys, err := auth.LoadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	if len(keys) > 0 {
+		authenticator, err := auth.NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	if cfg.JWKSFile != "" {
+		authenticator, err := auth.NewJWTAuthenticator(cfg.JWKSFile, cfg.JWTIssuer, cfg.JWTAudience)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	return authenticators, nil
+}
+
+func initTracer(ctx context.Context, otelURL url.URL, sampler sdktrace.Sampler) (*sdktrace.TracerProvider, error) {
 	options := []otlptracehttp.Option{
 		otlptracehttp.WithEndpoint(otelURL.Host), otlptracehttp.WithURLPath(otelURL.Path),
 	}
@@ -211,7 +544,7 @@ func initTracer(ctx context.Context, otelURL url.URL, fraction float64) (*sdktra
 	attributes = append(attributes, hostAttr.Attributes()...)
 
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(fraction)),
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
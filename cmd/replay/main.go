@@ -0,0 +1,250 @@
+// Command replay sends the calls of a recording back to a Calculator server
+// and reports the responses that differ from the recorded ones.
+//
+//	replay -file recording.jsonl -addr localhost:6000 -speed 2
+//
+// Calls are sent at the pace they were recorded, divided by -speed; a speed
+// of 0 sends them as fast as possible. Each call carries the metadata and
+// deadline it was recorded with, but the credentials of the flags and
+// without its idempotency key unless -keep-idempotency-keys is set, since
+// the server would answer with the stored response instead of executing the
+// call. Calls not sent before an interrupt are reported as skipped. The
+// command exits with status 1 when a response differs.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+	"github.com/rodneyosodo/gophercon/calculator/client"
+	"github.com/rodneyosodo/gophercon/calculator/idempotency"
+	"github.com/rodneyosodo/gophercon/calculator/record"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type flags struct {
+	file       string
+	addr       string
+	apiKey     string
+	token      string
+	caCert     string
+	clientCert string
+	clientKey  string
+	poolSize   int
+	speed      float64
+	keepKeys   bool
+}
+
+type operation func(calculator.Service, context.Context, int64, int64) (int64, error)
+
+var operations = map[string]operation{
+	calculator.Calculator_Add_FullMethodName:      calculator.Service.Add,
+	calculator.Calculator_Subtract_FullMethodName: calculator.Service.Subtract,
+	calculator.Calculator_Multiply_FullMethodName: calculator.Service.Multiply,
+	calculator.Calculator_Divide_FullMethodName:   calculator.Service.Divide,
+}
+
+// transportKeys are recorded metadata keys that are set by the connection
+// or the client's own tracing rather than replayed.
+var transportKeys = map[string]bool{
+	"content-type": true,
+	"user-agent":   true,
+	"te":           true,
+	"traceparent":  true,
+	"tracestate":   true,
+	"baggage":      true,
+}
+
+// outcome is the status and result of a call. The zero outcome is that of a
+// call that was not sent.
+type outcome struct {
+	code    string
+	message string
+	result  int64
+}
+
+func (o outcome) String() string {
+	if o.code == "OK" {
+		return fmt.Sprintf("OK %d", o.result)
+	}
+
+	return fmt.Sprintf("%s %q", o.code, o.message)
+}
+
+func main() {
+	var f flags
+	flag.StringVar(&f.file, "file", "recording.jsonl", "recording to replay")
+	flag.StringVar(&f.addr, "addr", "localhost:6000", "address of the Calculator server")
+	flag.StringVar(&f.apiKey, "api-key", os.Getenv("REPLAY_API_KEY"), "API key (defaults to $REPLAY_API_KEY)")
+	flag.StringVar(&f.token, "token", os.Getenv("REPLAY_TOKEN"), "JWT bearer token (defaults to $REPLAY_TOKEN)")
+	flag.StringVar(&f.caCert, "ca-cert", "", "CA certificate used to verify the server; enables TLS")
+	flag.StringVar(&f.clientCert, "cert", "", "client certificate for mTLS")
+	flag.StringVar(&f.clientKey, "key", "", "client key for mTLS")
+	flag.IntVar(&f.poolSize, "conns", 1, "number of pooled connections")
+	flag.Float64Var(&f.speed, "speed", 1, "pace relative to the recording; 0 sends calls as fast as possible")
+	flag.BoolVar(&f.keepKeys, "keep-idempotency-keys", false, "send the recorded idempotency keys")
+	flag.Parse()
+
+	if f.speed < 0 {
+		log.Fatalf("invalid speed: %v is negative", f.speed)
+	}
+
+	file, err := os.Open(f.file)
+	if err != nil {
+		log.Fatalf("failed to open recording: %s", err.Error())
+	}
+	entries, err := record.Read(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("failed to read recording: %s", err.Error())
+	}
+	if len(entries) == 0 {
+		log.Fatalf("recording %s is empty", f.file)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	c, err := client.New(clientOptions(f)...)
+	if err != nil {
+		log.Fatalf("failed to connect: %s", err.Error())
+	}
+	defer c.Close()
+
+	start := time.Now()
+	replayed := replay(ctx, c, entries, f)
+	elapsed := time.Since(start)
+
+	differed, skipped := 0, 0
+	for i, e := range entries {
+		if replayed[i] == (outcome{}) {
+			skipped++
+
+			continue
+		}
+		recorded, err := recordedOutcome(e)
+		if err != nil {
+			log.Fatalf("invalid entry %d: %s", i+1, err.Error())
+		}
+		if replayed[i] == recorded {
+			continue
+		}
+		differed++
+		fmt.Printf("#%d %s %s: recorded %s, replayed %s\n",
+			i+1, strings.TrimPrefix(e.Method, "/"), e.Request, recorded, replayed[i])
+	}
+
+	recordedSpan := entries[len(entries)-1].Time.Sub(entries[0].Time)
+	fmt.Printf("replayed %d calls recorded over %s in %s: %d matched, %d differed, %d skipped\n",
+		len(entries)-skipped, recordedSpan.Round(time.Millisecond), elapsed.Round(time.Millisecond), len(entries)-skipped-differed, differed, skipped)
+
+	if differed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay sends every entry at its scaled offset from the first one, without
+// waiting for earlier calls, so that the recorded concurrency is kept. Once
+// ctx is done no more calls are sent; their outcomes are left zero.
+func replay(ctx context.Context, svc calculator.Service, entries []record.Entry, f flags) []outcome {
+	outcomes := make([]outcome, len(entries))
+	start, first := time.Now(), entries[0].Time
+
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wait := time.Duration(0)
+		if f.speed > 0 {
+			wait = time.Until(start.Add(time.Duration(float64(e.Time.Sub(first)) / f.speed)))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcomes[i] = call(ctx, svc, e, f.keepKeys)
+		}()
+	}
+	wg.Wait()
+
+	return outcomes
+}
+
+func call(ctx context.Context, svc calculator.Service, e record.Entry, keepKeys bool) outcome {
+	op, ok := operations[e.Method]
+	if !ok {
+		return outcome{code: "Unimplemented", message: "method is not replayable"}
+	}
+	var req calculator.Request
+	if err := protojson.Unmarshal(e.Request, &req); err != nil {
+		return outcome{code: "InvalidArgument", message: err.Error()}
+	}
+
+	md := metadata.MD{}
+	for key, values := range e.Metadata {
+		// Credentials come from the flags, even if an older recording holds
+		// some.
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") || transportKeys[key] || record.Credential(key) {
+			continue
+		}
+		if key == idempotency.Header && !keepKeys {
+			continue
+		}
+		md[key] = values
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	result, err := op(svc, ctx, req.GetA(), req.GetB())
+	s := status.Convert(err)
+
+	return outcome{code: s.Code().String(), message: s.Message(), result: result}
+}
+
+func recordedOutcome(e record.Entry) (outcome, error) {
+	o := outcome{code: e.Code, message: e.Message}
+	if e.Code == "OK" {
+		var resp calculator.Response
+		if err := protojson.Unmarshal(e.Response, &resp); err != nil {
+			return outcome{}, err
+		}
+		o.result = resp.GetResult()
+	}
+
+	return o, nil
+}
+
+func clientOptions(f flags) []client.Option {
+	opts := []client.Option{client.WithAddress(f.addr), client.WithPoolSize(f.poolSize)}
+	switch {
+	case f.token != "":
+		opts = append(opts, client.WithBearerToken(f.token))
+	case f.apiKey != "":
+		opts = append(opts, client.WithAPIKey(f.apiKey))
+	}
+	if f.caCert != "" {
+		opts = append(opts, client.WithTLSFiles(f.caCert, f.clientCert, f.clientKey))
+	}
+
+	return opts
+}
@@ -0,0 +1,40 @@
+// Command slo generates Prometheus recording and burn-rate alerting rules
+// from a file of service level objectives.
+//
+//	slo -config prometheus/slo.yaml -out prometheus/rules/slo.yaml
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/rodneyosodo/gophercon/internal/slo"
+)
+
+func main() {
+	config := flag.String("config", "prometheus/slo.yaml", "service level objectives file")
+	out := flag.String("out", "", "rule file to write; defaults to stdout")
+	flag.Parse()
+
+	cfg, err := slo.Load(*config)
+	if err != nil {
+		log.Fatalf("invalid objectives: %s", err.Error())
+	}
+
+	data, err := cfg.Rules().Marshal()
+	if err != nil {
+		log.Fatalf("failed to render rules: %s", err.Error())
+	}
+
+	if *out == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			log.Fatalf("failed to write rules: %s", err.Error())
+		}
+
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil { //nolint:gosec // rule files are read by Prometheus
+		log.Fatalf("failed to write rules: %s", err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+// Package audit keeps a tamper-evident record of every calculation.
+//
+// Records are appended to JSON Lines files. Each record carries the hash of
+// the one before it, across file rotations, so that editing, removing or
+// reordering records breaks the chain and is reported by Verify. The trail
+// is separate from the operational logs: it is neither sampled nor subject
+// to log levels.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrChainBroken indicates a record whose sequence number or hashes do not
+// follow from the record before it.
+var ErrChainBroken = errors.New("audit hash chain broken")
+
+// Record is one calculation.
+type Record struct {
+	// Seq numbers the records from 1 without gaps.
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal,omitempty"`
+	Operation string    `json:"operation"`
+	A         string    `json:"a,omitempty"`
+	B         string    `json:"b,omitempty"`
+	Result    string    `json:"result,omitempty"`
+	// Status is the gRPC status code name of the call, e.g. OK or Unknown.
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+	// PrevHash is the hash of the previous record, empty for the first.
+	PrevHash string `json:"prev_hash"`
+	// Hash is the SHA-256 of the record encoded without it.
+	Hash string `json:"hash"`
+}
+
+// Sink appends records to an audit trail. Implementations assign Seq,
+// PrevHash and Hash and must be safe for concurrent use.
+type Sink interface {
+	Write(ctx context.Context, r Record) error
+	Close() error
+}
+
+// digest returns the hash of r with its Hash field cleared.
+func (r Record) digest() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// currentFile is the file records are appended to.
+	currentFile = "audit.jsonl"
+	// rotatedPattern names a rotated file after the sequence number of its
+	// first record, zero-padded so that names sort in chain order.
+	rotatedPattern = "audit-%020d.jsonl"
+	rotatedGlob    = "audit-*.jsonl"
+
+	dirMode  = 0o700
+	fileMode = 0o600
+)
+
+var _ Sink = (*FileSink)(nil)
+
+// FileSink appends records to audit.jsonl in a directory. Once the file
+// reaches maxSize it is renamed after its first sequence number and a new
+// one is started. Rotated files are never deleted. Every record is synced
+// to disk before Write returns.
+type FileSink struct {
+	dir     string
+	maxSize int64
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	firstSeq uint64
+	last     Record
+}
+
+// NewFileSink opens the audit trail in dir, creating it if needed, and
+// resumes the chain from its last record.
+func NewFileSink(dir string, maxSize int64) (*FileSink, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	s := &FileSink{dir: dir, maxSize: maxSize}
+
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	// The last record is in the current file, or in the last rotated file
+	// when the process stopped right after a rotation.
+	for i := len(files) - 1; i >= 0 && s.last.Seq == 0; i-- {
+		last, err := lastRecord(files[i])
+		if err != nil {
+			return nil, err
+		}
+		s.last = last
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	path := filepath.Join(s.dir, currentFile)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+
+	s.file, s.size, s.firstSeq = file, info.Size(), 0
+	if s.size > 0 {
+		first, err := firstRecord(path)
+		if err != nil {
+			file.Close()
+
+			return err
+		}
+		s.firstSeq = first.Seq
+	}
+
+	return nil
+}
+
+// Write chains r to the previous record and appends it.
+func (s *FileSink) Write(_ context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	r.Seq = s.last.Seq + 1
+	r.PrevHash = s.last.Hash
+	hash, err := r.digest()
+	if err != nil {
+		return err
+	}
+	r.Hash = hash
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if s.size > 0 && s.maxSize > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit record: %w", err)
+	}
+	s.size += int64(len(line))
+	if s.firstSeq == 0 {
+		s.firstSeq = r.Seq
+	}
+	s.last = r
+
+	return nil
+}
+
+// rotate renames the current file and opens a new one. When the rename
+// fails the current file is reopened, so that the sink keeps working and
+// the rotation is retried by the next write.
+func (s *FileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to close audit file: %w", err), s.open())
+	}
+
+	rotated := filepath.Join(s.dir, fmt.Sprintf(rotatedPattern, s.firstSeq))
+	if err := os.Rename(filepath.Join(s.dir, currentFile), rotated); err != nil {
+		return errors.Join(fmt.Errorf("failed to rotate audit file: %w", err), s.open())
+	}
+
+	return s.open()
+}
+
+// Close closes the current file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
+
+// Files returns the files of the audit trail in dir in chain order: the
+// rotated files followed by the current one.
+func Files(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, rotatedGlob))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	current := filepath.Join(dir, currentFile)
+	if _, err := os.Stat(current); err == nil {
+		files = append(files, current)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func firstRecord(path string) (Record, error) {
+	var first Record
+	err := scan(path, func(_ int, r Record) bool {
+		first = r
+
+		return false
+	})
+
+	return first, err
+}
+
+func lastRecord(path string) (Record, error) {
+	var last Record
+	err := scan(path, func(_ int, r Record) bool {
+		last = r
+
+		return true
+	})
+
+	return last, err
+}
+
+// scan decodes the records of path in order until fn returns false.
+func scan(path string, fn func(line int, r Record) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if !fn(line, r) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
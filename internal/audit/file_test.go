@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSinkRotationFailure(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, 1)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	r := Record{Operation: "Add", Status: "OK"}
+	if err := sink.Write(context.Background(), r); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// A directory in place of the rotated file makes the rename fail.
+	blocker := filepath.Join(dir, fmt.Sprintf(rotatedPattern, 1))
+	if err := os.MkdirAll(filepath.Join(blocker, "blocker"), dirMode); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := sink.Write(context.Background(), r); err == nil {
+		t.Fatal("Write() error = nil, want the rotation error")
+	}
+
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if err := sink.Write(context.Background(), r); err != nil {
+		t.Fatalf("Write() after a failed rotation error = %v", err)
+	}
+
+	summary, err := Verify(dir)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if summary.Records != 2 {
+		t.Errorf("Verify() = %d records, want 2", summary.Records)
+	}
+}
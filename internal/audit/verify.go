@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"fmt"
+)
+
+// Summary describes a verified audit trail.
+type Summary struct {
+	Files   int
+	Records uint64
+	// LastHash is the hash of the last record. Keeping it elsewhere detects
+	// records removed from the end of the trail.
+	LastHash string
+}
+
+// Verify checks the hash chain of the audit trail in dir. It returns the
+// first record that does not follow from the one before it, wrapped in
+// ErrChainBroken with its file and line.
+func Verify(dir string) (Summary, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var summary Summary
+	var prev Record
+	for _, path := range files {
+		summary.Files++
+		var broken error
+		err := scan(path, func(line int, r Record) bool {
+			if err := check(prev, r); err != nil {
+				broken = fmt.Errorf("%w: %s:%d: %w", ErrChainBroken, path, line, err)
+
+				return false
+			}
+			prev = r
+			summary.Records++
+
+			return true
+		})
+		if err != nil {
+			return summary, err
+		}
+		if broken != nil {
+			return summary, broken
+		}
+	}
+	summary.LastHash = prev.Hash
+
+	return summary, nil
+}
+
+// check verifies that r follows prev.
+func check(prev, r Record) error {
+	if r.Seq != prev.Seq+1 {
+		return fmt.Errorf("seq %d follows %d", r.Seq, prev.Seq)
+	}
+	if r.PrevHash != prev.Hash {
+		return fmt.Errorf("seq %d prev_hash does not match the hash of seq %d", r.Seq, prev.Seq)
+	}
+	hash, err := r.digest()
+	if err != nil {
+		return err
+	}
+	if r.Hash != hash {
+		return fmt.Errorf("seq %d hash does not match its contents", r.Seq)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// records is the number of records written by writeTrail.
+const records = 6
+
+// writeTrail writes records to a new trail in a temporary directory, small
+// enough files for it to rotate every two records, and returns its files.
+func writeTrail(t *testing.T) (string, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, 500)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	for i := range records {
+		r := Record{Time: time.Unix(int64(i), 0).UTC(), Operation: "Add", A: "1", B: "2", Result: "3", Status: "OK"}
+		if err := sink.Write(context.Background(), r); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	if len(files) < 3 {
+		t.Fatalf("Files() = %d files, want at least 3 to cover rotations", len(files))
+	}
+
+	return dir, files
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), fileMode); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func(t *testing.T, files []string)
+		broken bool
+	}{
+		{
+			name:   "intact",
+			tamper: func(*testing.T, []string) {},
+		},
+		{
+			name: "edited record",
+			tamper: func(t *testing.T, files []string) {
+				lines := readLines(t, files[0])
+				lines[1] = strings.Replace(lines[1], `"operation":"Add"`, `"operation":"Divide"`, 1)
+				writeLines(t, files[0], lines)
+			},
+			broken: true,
+		},
+		{
+			name: "removed record",
+			tamper: func(t *testing.T, files []string) {
+				lines := readLines(t, files[1])
+				writeLines(t, files[1], lines[1:])
+			},
+			broken: true,
+		},
+		{
+			name: "removed rotated file",
+			tamper: func(t *testing.T, files []string) {
+				if err := os.Remove(files[1]); err != nil {
+					t.Fatalf("Remove() error = %v", err)
+				}
+			},
+			broken: true,
+		},
+		{
+			name: "reordered records",
+			tamper: func(t *testing.T, files []string) {
+				lines := readLines(t, files[0])
+				lines[0], lines[1] = lines[1], lines[0]
+				writeLines(t, files[0], lines)
+			},
+			broken: true,
+		},
+		{
+			name: "reordered across a rotation",
+			tamper: func(t *testing.T, files []string) {
+				first, second := readLines(t, files[0]), readLines(t, files[1])
+				first[len(first)-1], second[0] = second[0], first[len(first)-1]
+				writeLines(t, files[0], first)
+				writeLines(t, files[1], second)
+			},
+			broken: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, files := writeTrail(t)
+			tc.tamper(t, files)
+
+			summary, err := Verify(dir)
+			if tc.broken {
+				if !errors.Is(err, ErrChainBroken) {
+					t.Fatalf("Verify() error = %v, want %v", err, ErrChainBroken)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if summary.Records != records || summary.Files != len(files) {
+				t.Errorf("Verify() = %+v, want %d records in %d files", summary, records, len(files))
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+// Package config loads the configuration of the Calculator server.
+//
+// Values come from, in increasing order of precedence, the defaults, an
+// optional YAML or TOML file and GOPHERCON_* environment variables. Fields
+// tagged reload:"true" are applied again when the file changes; the others
+// take effect on restart.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v11"
+	"github.com/rodneyosodo/gophercon/internal/lokilog"
+	"github.com/rodneyosodo/gophercon/internal/redact"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+var (
+	// ErrUnknownFormat indicates a configuration file whose extension is not
+	// .yaml, .yml or .toml.
+	ErrUnknownFormat = errors.New("unknown configuration file format")
+	// ErrUnknownField indicates a key in the configuration file that does not
+	// match any field.
+	ErrUnknownField = errors.New("unknown configuration field")
+)
+
+// Config is the configuration of the Calculator server, e.g.:
+//
+//	log_level: info
+//	trace_ratio: 0.1
+//	faults:
+//	  error_rate: 0.2
+//	limits:
+//	  max_concurrent_calls: 100
+type Config struct {
+	LogLevel             string        `env:"GOPHERCON_LOG_LEVEL"              reload:"true" toml:"log_level"              yaml:"log_level"`
+	LogSampling          LogSampling   `envPrefix:"GOPHERCON_LOG_SAMPLING_"    reload:"true" toml:"log_sampling"           yaml:"log_sampling"`
+	Addr                 string        `env:"GOPHERCON_ADDR"                                 toml:"addr"                   yaml:"addr"`
+	PrometheusEndpoint   string        `env:"GOPHERCON_PROMETHEUS_ENDPOINT"                  toml:"prometheus_endpoint"    yaml:"prometheus_endpoint"`
+	ReadTimeout          time.Duration `env:"GOPHERCON_READ_TIMEOUT"                         toml:"read_timeout"           yaml:"read_timeout"`
+	WriteTimeout         time.Duration `env:"GOPHERCON_WRITE_TIMEOUT"                        toml:"write_timeout"          yaml:"write_timeout"`
+	OTELURL              string        `env:"GOPHERCON_OTEL_URL"                             toml:"otel_url"               yaml:"otel_url"`
+	TraceRatio           float64       `env:"GOPHERCON_TRACE_RATIO"            reload:"true" toml:"trace_ratio"            yaml:"trace_ratio"`
+	LokiURL              string        `env:"GOPHERCON_LOKI_URL"                             toml:"loki_url"               yaml:"loki_url"`
+	Loki                 Loki          `envPrefix:"GOPHERCON_LOKI_"                          toml:"loki"                   yaml:"loki"`
+	PyroScopeURL         string        `env:"GOPHERCON_PYROSCOPE_URL"                        toml:"pyroscope_url"          yaml:"pyroscope_url"`
+	APIKeys              []string      `env:"GOPHERCON_API_KEYS"                             toml:"api_keys"               yaml:"api_keys"               envSeparator:";"`
+	APIKeysFile          string        `env:"GOPHERCON_API_KEYS_FILE"                        toml:"api_keys_file"          yaml:"api_keys_file"`
+	JWKSFile             string        `env:"GOPHERCON_JWKS_FILE"                            toml:"jwks_file"              yaml:"jwks_file"`
+	JWTIssuer            string        `env:"GOPHERCON_JWT_ISSUER"                           toml:"jwt_issuer"             yaml:"jwt_issuer"`
+	JWTAudience          string        `env:"GOPHERCON_JWT_AUDIENCE"                         toml:"jwt_audience"           yaml:"jwt_audience"`
+	AuthzPolicyFile      string        `env:"GOPHERCON_AUTHZ_POLICY_FILE"                    toml:"authz_policy_file"      yaml:"authz_policy_file"`
+	TLSCertFile          string        `env:"GOPHERCON_TLS_CERT_FILE"                        toml:"tls_cert_file"          yaml:"tls_cert_file"`
+	TLSKeyFile           string        `env:"GOPHERCON_TLS_KEY_FILE"                         toml:"tls_key_file"           yaml:"tls_key_file"`
+	TLSClientCAFile      string        `env:"GOPHERCON_TLS_CLIENT_CA_FILE"                   toml:"tls_client_ca_file"     yaml:"tls_client_ca_file"`
+	GatewayAddr          string        `env:"GOPHERCON_GATEWAY_ADDR"                         toml:"gateway_addr"           yaml:"gateway_addr"`
+	AdminAddr            string        `env:"GOPHERCON_ADMIN_ADDR"                           toml:"admin_addr"             yaml:"admin_addr"`
+	ProfileTypes         []string      `env:"GOPHERCON_PROFILE_TYPES"                        toml:"profile_types"          yaml:"profile_types"`
+	BlockProfileRate     int           `env:"GOPHERCON_BLOCK_PROFILE_RATE"                   toml:"block_profile_rate"     yaml:"block_profile_rate"`
+	MutexProfileFraction int           `env:"GOPHERCON_MUTEX_PROFILE_FRACTION"               toml:"mutex_profile_fraction" yaml:"mutex_profile_fraction"`
+	Redaction            Redaction     `envPrefix:"GOPHERCON_REDACTION_"       reload:"true" toml:"redaction"              yaml:"redaction"`
+	Faults               Faults        `envPrefix:"GOPHERCON_FAULT_"           reload:"true" toml:"faults"                 yaml:"faults"`
+	Limits               Limits        `envPrefix:"GOPHERCON_LIMIT_"           reload:"true" toml:"limits"                 yaml:"limits"`
+	Deadlines            Deadlines     `envPrefix:"GOPHERCON_DEADLINE_"        reload:"true" toml:"deadlines"              yaml:"deadlines"`
+	Workload             Workload      `envPrefix:"GOPHERCON_WORKLOAD_"        reload:"true" toml:"workload"               yaml:"workload"`
+	Audit                Audit         `envPrefix:"GOPHERCON_AUDIT_"                         toml:"audit"                  yaml:"audit"`
+	History              History       `envPrefix:"GOPHERCON_HISTORY_"                       toml:"history"                yaml:"history"`
+	RecordingFile        string        `env:"GOPHERCON_RECORDING_FILE"                       toml:"recording_file"         yaml:"recording_file"`
+	Idempotency          Idempotency   `envPrefix:"GOPHERCON_IDEMPOTENCY_"                   toml:"idempotency"            yaml:"idempotency"`
+}
+
+// Redaction is the policy applied to the fields of calculator logs, spans,
+// audit records and history responses, such as a, b, result, principal and
+// error.
+type Redaction struct {
+	// Default is the action for fields not listed in Fields: allow, hash,
+	// mask or drop.
+	Default redact.Action `env:"DEFAULT" toml:"default" yaml:"default"`
+	// Fields maps field names to actions. In the environment they are
+	// written as principal:hash,a:mask.
+	Fields map[string]redact.Action `env:"FIELDS" toml:"fields" yaml:"fields"`
+	// HashKey keys the hashes so that they correlate across restarts.
+	HashKey string `env:"HASH_KEY" toml:"hash_key" yaml:"hash_key"`
+}
+
+// Faults is the fault profile injected into calculator operations.
+type Faults struct {
+	// ErrorRate is the share of calls failing with a random error.
+	ErrorRate float64 `env:"ERROR_RATE" toml:"error_rate" yaml:"error_rate"`
+	// Latency is added to every call.
+	Latency time.Duration `env:"LATENCY" toml:"latency" yaml:"latency"`
+}
+
+// LogSampling thins out repetitive info and debug records. Warnings, errors
+// and slow calls are always logged.
+type LogSampling struct {
+	// Interval is the sampling window. Zero disables sampling.
+	Interval time.Duration `env:"INTERVAL" toml:"interval" yaml:"interval"`
+	// First is the number of records with the same message and operation
+	// kept in each interval.
+	First int `env:"FIRST" toml:"first" yaml:"first"`
+	// Thereafter keeps one in every Thereafter records once First is
+	// reached. Zero drops them all.
+	Thereafter int `env:"THEREAFTER" toml:"thereafter" yaml:"thereafter"`
+	// SlowThreshold always keeps records of calls lasting at least this
+	// long.
+	SlowThreshold time.Duration `env:"SLOW_THRESHOLD" toml:"slow_threshold" yaml:"slow_threshold"`
+}
+
+// Loki configures the Loki client used when loki_url is set.
+type Loki struct {
+	TenantID string `env:"TENANT_ID" toml:"tenant_id" yaml:"tenant_id"`
+	// Labels are added to every stream, e.g. env, region or version. In the
+	// environment they are written as env:prod,region:eu.
+	Labels map[string]string `env:"LABELS" toml:"labels" yaml:"labels"`
+	// PromotedLabels are the slog attributes used as stream labels. Other
+	// attributes are written to the log line.
+	PromotedLabels     []string      `env:"PROMOTED_LABELS"      toml:"promoted_labels"      yaml:"promoted_labels"`
+	BatchSize          int           `env:"BATCH_SIZE"           toml:"batch_size"           yaml:"batch_size"`
+	BatchWait          time.Duration `env:"BATCH_WAIT"           toml:"batch_wait"           yaml:"batch_wait"`
+	Timeout            time.Duration `env:"TIMEOUT"              toml:"timeout"              yaml:"timeout"`
+	MaxRetries         int           `env:"MAX_RETRIES"          toml:"max_retries"          yaml:"max_retries"`
+	QueueSize          int           `env:"QUEUE_SIZE"           toml:"queue_size"           yaml:"queue_size"`
+	Username           string        `env:"USERNAME"             toml:"username"             yaml:"username"`
+	Password           string        `env:"PASSWORD"             toml:"password"             yaml:"password"`
+	BearerToken        string        `env:"BEARER_TOKEN"         toml:"bearer_token"         yaml:"bearer_token"`
+	BearerTokenFile    string        `env:"BEARER_TOKEN_FILE"    toml:"bearer_token_file"    yaml:"bearer_token_file"`
+	CAFile             string        `env:"CA_FILE"              toml:"ca_file"              yaml:"ca_file"`
+	CertFile           string        `env:"CERT_FILE"            toml:"cert_file"            yaml:"cert_file"`
+	KeyFile            string        `env:"KEY_FILE"             toml:"key_file"             yaml:"key_file"`
+	InsecureSkipVerify bool          `env:"INSECURE_SKIP_VERIFY" toml:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// Limits bound the load the calculator accepts.
+type Limits struct {
+	// MaxConcurrentCalls rejects calculator calls beyond this many in flight.
+	// Zero means unlimited.
+	MaxConcurrentCalls int `env:"MAX_CONCURRENT_CALLS" toml:"max_concurrent_calls" yaml:"max_concurrent_calls"`
+}
+
+// Workload is the simulated work of Add, which puts pressure on memory, and
+// of Subtract, which is slow.
+type Workload struct {
+	// AddMemory is the number of bytes each Add call allocates and fills.
+	AddMemory int64 `env:"ADD_MEMORY" toml:"add_memory" yaml:"add_memory"`
+	// AddHold is how long Add keeps the memory before answering.
+	AddHold time.Duration `env:"ADD_HOLD" toml:"add_hold" yaml:"add_hold"`
+	// SubtractDelay is how long Subtract waits before answering.
+	SubtractDelay time.Duration `env:"SUBTRACT_DELAY" toml:"subtract_delay" yaml:"subtract_delay"`
+}
+
+// Deadlines are the default deadlines of the operations, applied to calls
+// whose caller set none. Zero means none.
+type Deadlines struct {
+	Add      time.Duration `env:"ADD"      toml:"add"      yaml:"add"`
+	Subtract time.Duration `env:"SUBTRACT" toml:"subtract" yaml:"subtract"`
+	Multiply time.Duration `env:"MULTIPLY" toml:"multiply" yaml:"multiply"`
+	Divide   time.Duration `env:"DIVIDE"   toml:"divide"   yaml:"divide"`
+}
+
+// Audit configures the audit trail of calculations.
+type Audit struct {
+	// Dir is the directory of the audit files. Empty disables auditing.
+	Dir string `env:"DIR" toml:"dir" yaml:"dir"`
+	// MaxSize is the size in bytes at which the current file is rotated.
+	MaxSize int64 `env:"MAX_SIZE" toml:"max_size" yaml:"max_size"`
+	// FailClosed fails calls whose record cannot be written with
+	// Unavailable. When false the failure is only logged.
+	FailClosed bool `env:"FAIL_CLOSED" toml:"fail_closed" yaml:"fail_closed"`
+}
+
+// History configures the store of completed calculations.
+type History struct {
+	// Store is the kind of store: sqlite, the default, or none to disable
+	// the history.
+	Store string `env:"STORE" toml:"store" yaml:"store"`
+	// Path is the SQLite database file.
+	Path string `env:"PATH" toml:"path" yaml:"path"`
+}
+
+// Idempotency configures the deduplication of calls carrying an
+// idempotency key.
+type Idempotency struct {
+	// TTL is how long responses are kept. Zero disables deduplication.
+	TTL time.Duration `env:"TTL" toml:"ttl" yaml:"ttl"`
+	// MaxKeys bounds the number of responses kept in memory.
+	MaxKeys int `env:"MAX_KEYS" toml:"max_keys" yaml:"max_keys"`
+}
+
+// Default returns the configuration used when neither the file nor the
+// environment sets a field.
+func Default() Config {
+	return Config{
+		LogLevel: "info",
+		LogSampling: LogSampling{
+			Interval:      time.Second,
+			First:         10,                     //nolint:mnd // default
+			Thereafter:    100,                    //nolint:mnd // default
+			SlowThreshold: 500 * time.Millisecond, //nolint:mnd // default
+		},
+		Addr:               ":6000",
+		PrometheusEndpoint: ":6001",
+		ReadTimeout:        10 * time.Second, //nolint:mnd // default
+		WriteTimeout:       10 * time.Second, //nolint:mnd // default
+		TraceRatio:         0.1,              //nolint:mnd // default
+		Loki: Loki{
+			TenantID:       "gophercon",
+			PromotedLabels: []string{"service", "level", "component", "log_type"},
+			BatchSize:      1024 * 1024,      //nolint:mnd // 1 MiB
+			BatchWait:      time.Second,      //nolint:mnd // default
+			Timeout:        10 * time.Second, //nolint:mnd // default
+			MaxRetries:     10,               //nolint:mnd // default
+			QueueSize:      10000,            //nolint:mnd // default
+		},
+		GatewayAddr: ":6002",
+		ProfileTypes: []string{
+			"cpu", "alloc_objects", "alloc_space", "inuse_objects", "inuse_space", "goroutines", "mutex_count",
+		},
+		Redaction: Redaction{Default: redact.Allow},
+		Faults:    Faults{ErrorRate: 0.2}, //nolint:mnd // the error rate the demo always had
+		Deadlines: Deadlines{
+			Add:      10 * time.Second, //nolint:mnd // default
+			Subtract: 10 * time.Second, //nolint:mnd // default
+			Multiply: 5 * time.Second,  //nolint:mnd // the timeout Multiply always had
+			Divide:   time.Second,
+		},
+		Workload: Workload{
+			AddMemory:     500 << 20,       //nolint:mnd // the 500 MiB Add always allocated
+			SubtractDelay: 5 * time.Second, //nolint:mnd // the delay Subtract always had
+		},
+		Audit:   Audit{MaxSize: 100 << 20, FailClosed: true}, //nolint:mnd // 100 MiB
+		History: History{Store: "sqlite", Path: "history.db"},
+		Idempotency: Idempotency{
+			TTL:     10 * time.Minute, //nolint:mnd // default
+			MaxKeys: 10000,            //nolint:mnd // default
+		},
+	}
+}
+
+// Load returns the defaults overridden by file, when not empty, and then by
+// the environment. The result is not validated.
+func Load(file string) (Config, error) {
+	cfg := Default()
+
+	if file != "" {
+		if err := decodeFile(file, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := env.Parse(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse environment: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// decodeFile strictly decodes file over cfg, so that fields missing from
+// the file keep their current value.
+func decodeFile(file string, cfg *Config) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// An empty file is a valid configuration.
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse configuration: %w", err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse configuration: %w", err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%w: %s", ErrUnknownField, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, file)
+	}
+
+	return nil
+}
+
+// Validate checks every field and reports all the problems found at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %w", err))
+	}
+
+	if c.LogSampling.Interval < 0 || c.LogSampling.First < 0 || c.LogSampling.Thereafter < 0 || c.LogSampling.SlowThreshold < 0 {
+		errs = append(errs, errors.New("log_sampling: must not be negative"))
+	}
+
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr: is required"))
+	}
+	for _, addr := range []struct{ name, value string }{
+		{"addr", c.Addr},
+		{"prometheus_endpoint", c.PrometheusEndpoint},
+		{"gateway_addr", c.GatewayAddr},
+		{"admin_addr", c.AdminAddr},
+	} {
+		if addr.value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr.name, err))
+		}
+	}
+
+	if c.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("read_timeout: must be positive"))
+	}
+	if c.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("write_timeout: must be positive"))
+	}
+
+	for _, u := range []struct{ name, value string }{
+		{"otel_url", c.OTELURL},
+		{"loki_url", c.LokiURL},
+		{"pyroscope_url", c.PyroScopeURL},
+	} {
+		if u.value == "" {
+			continue
+		}
+		if err := validateURL(u.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.name, err))
+		}
+	}
+	errs = append(errs, c.Loki.validate())
+	if c.TraceRatio < 0 || c.TraceRatio > 1 {
+		errs = append(errs, fmt.Errorf("trace_ratio: %v is not between 0 and 1", c.TraceRatio))
+	}
+
+	if (c.JWTIssuer != "" || c.JWTAudience != "") && c.JWKSFile == "" {
+		errs = append(errs, errors.New("jwt_issuer, jwt_audience: require jwks_file"))
+	}
+	if c.AdminAddr != "" && len(c.APIKeys) == 0 && c.APIKeysFile == "" && c.JWKSFile == "" {
+		errs = append(errs, errors.New("admin_addr: requires api_keys, api_keys_file or jwks_file"))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls_cert_file, tls_key_file: must be set together"))
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		errs = append(errs, errors.New("tls_client_ca_file: requires tls_cert_file"))
+	}
+
+	if c.BlockProfileRate < 0 {
+		errs = append(errs, errors.New("block_profile_rate: must not be negative"))
+	}
+	if c.MutexProfileFraction < 0 {
+		errs = append(errs, errors.New("mutex_profile_fraction: must not be negative"))
+	}
+
+	if err := redact.Policy(c.Redaction).Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("redaction: %w", err))
+	}
+
+	if c.Faults.ErrorRate < 0 || c.Faults.ErrorRate > 1 {
+		errs = append(errs, fmt.Errorf("faults.error_rate: %v is not between 0 and 1", c.Faults.ErrorRate))
+	}
+	if c.Faults.Latency < 0 {
+		errs = append(errs, errors.New("faults.latency: must not be negative"))
+	}
+	if c.Limits.MaxConcurrentCalls < 0 {
+		errs = append(errs, errors.New("limits.max_concurrent_calls: must not be negative"))
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"add", c.Deadlines.Add},
+		{"subtract", c.Deadlines.Subtract},
+		{"multiply", c.Deadlines.Multiply},
+		{"divide", c.Deadlines.Divide},
+	} {
+		if d.value < 0 {
+			errs = append(errs, fmt.Errorf("deadlines.%s: must not be negative", d.name))
+		}
+	}
+	if c.Workload.AddMemory < 0 {
+		errs = append(errs, errors.New("workload.add_memory: must not be negative"))
+	}
+	if c.Workload.AddHold < 0 {
+		errs = append(errs, errors.New("workload.add_hold: must not be negative"))
+	}
+	if c.Workload.SubtractDelay < 0 {
+		errs = append(errs, errors.New("workload.subtract_delay: must not be negative"))
+	}
+	if c.Audit.MaxSize <= 0 {
+		errs = append(errs, errors.New("audit.max_size: must be positive"))
+	}
+	if c.Idempotency.TTL < 0 {
+		errs = append(errs, errors.New("idempotency.ttl: must not be negative"))
+	}
+	if c.Idempotency.MaxKeys <= 0 {
+		errs = append(errs, errors.New("idempotency.max_keys: must be positive"))
+	}
+	switch c.History.Store {
+	case "none":
+	case "sqlite":
+		if c.History.Path == "" {
+			errs = append(errs, errors.New("history.path: required by the sqlite store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("history.store: %q is not sqlite or none", c.History.Store))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (l Loki) validate() error {
+	var errs []error
+
+	if err := lokilog.Config(l).Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("loki: %w", err))
+	}
+	for _, v := range []struct {
+		name  string
+		value int64
+	}{
+		{"batch_size", int64(l.BatchSize)},
+		{"batch_wait", int64(l.BatchWait)},
+		{"timeout", int64(l.Timeout)},
+		{"max_retries", int64(l.MaxRetries)},
+		{"queue_size", int64(l.QueueSize)},
+	} {
+		if v.value <= 0 {
+			errs = append(errs, fmt.Errorf("loki.%s: must be positive", v.name))
+		}
+	}
+	if (l.Username != "" || l.Password != "") && (l.BearerToken != "" || l.BearerTokenFile != "") {
+		errs = append(errs, errors.New("loki: basic and bearer authentication are mutually exclusive"))
+	}
+	if l.BearerToken != "" && l.BearerTokenFile != "" {
+		errs = append(errs, errors.New("loki.bearer_token, loki.bearer_token_file: are mutually exclusive"))
+	}
+	if (l.CertFile == "") != (l.KeyFile == "") {
+		errs = append(errs, errors.New("loki.cert_file, loki.key_file: must be set together"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q is not http or https", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("host is required")
+	}
+
+	return nil
+}
+
+// Level returns the parsed log level. It must only be called on a valid
+// configuration.
+func (c Config) Level() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.LogLevel))
+
+	return level
+}
+
+// Redacted returns a copy of the configuration that is safe to print: API
+// keys and URL passwords are replaced.
+func (c Config) Redacted() Config {
+	if len(c.APIKeys) > 0 {
+		keys := make([]string, len(c.APIKeys))
+		for i := range keys {
+			keys[i] = redacted
+		}
+		c.APIKeys = keys
+	}
+	c.OTELURL = redactURL(c.OTELURL)
+	c.LokiURL = redactURL(c.LokiURL)
+	if c.Redaction.HashKey != "" {
+		c.Redaction.HashKey = redacted
+	}
+	if c.Loki.Password != "" {
+		c.Loki.Password = redacted
+	}
+	if c.Loki.BearerToken != "" {
+		c.Loki.BearerToken = redacted
+	}
+	c.PyroScopeURL = redactURL(c.PyroScopeURL)
+
+	return c
+}
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	} else {
+		u.User = url.User(redacted)
+	}
+
+	return u.String()
+}
+
+// Marshal encodes the configuration as YAML.
+func (c Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) //nolint:mnd // two-space indent
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RestartRequired returns the names of the fields that differ between c and
+// next and are only applied on restart.
+func (c Config) RestartRequired(next Config) []string {
+	var fields []string
+
+	current, updated := reflect.ValueOf(c), reflect.ValueOf(next)
+	for i := range current.NumField() {
+		field := current.Type().Field(i)
+		if field.Tag.Get("reload") == "true" {
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			fields = append(fields, field.Tag.Get("yaml"))
+		}
+	}
+
+	return fields
+}
+
+// Reloaded returns c with the fields that are applied on reload taken from
+// next. The others keep their value until restart.
+func (c Config) Reloaded(next Config) Config {
+	current, updated := reflect.ValueOf(&c).Elem(), reflect.ValueOf(next)
+	for i := range current.NumField() {
+		if current.Type().Field(i).Tag.Get("reload") == "true" {
+			current.Field(i).Set(updated.Field(i))
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/internal/filewatch"
+)
+
+// Watch reloads file whenever it changes until ctx is done and passes each
+// valid configuration to apply. Invalid configurations are logged and
+// ignored. Changes to fields that are not reloadable are logged as requiring
+// a restart and not applied: those fields keep the value current gave them.
+func Watch(ctx context.Context, file string, current Config, interval time.Duration, logger *slog.Logger, apply func(Config)) {
+	filewatch.Watch(ctx, interval, func() {
+		next, err := Load(file)
+		if err == nil {
+			err = next.Validate()
+		}
+		if err != nil {
+			logger.Error("Failed to reload configuration", slog.String("file", file), slog.String("error", err.Error()))
+
+			return
+		}
+
+		if fields := current.RestartRequired(next); len(fields) > 0 {
+			logger.Warn("Configuration changes require a restart", slog.String("file", file), slog.Any("fields", fields))
+		}
+		current = current.Reloaded(next)
+		apply(current)
+		logger.Info("Configuration reloaded", slog.String("file", file))
+	}, file)
+}
@@ -0,0 +1,175 @@
+// Package dashboards builds the Grafana dashboards of the Calculator service
+// from the metrics, logs and traces it emits.
+package dashboards
+
+import (
+	"github.com/grafana/grafana-foundation-sdk/go/cog"
+	"github.com/grafana/grafana-foundation-sdk/go/common"
+	"github.com/grafana/grafana-foundation-sdk/go/dashboard"
+	"github.com/grafana/grafana-foundation-sdk/go/logs"
+	"github.com/grafana/grafana-foundation-sdk/go/loki"
+	"github.com/grafana/grafana-foundation-sdk/go/prometheus"
+	"github.com/grafana/grafana-foundation-sdk/go/stat"
+	"github.com/grafana/grafana-foundation-sdk/go/table"
+	"github.com/grafana/grafana-foundation-sdk/go/tempo"
+	"github.com/grafana/grafana-foundation-sdk/go/timeseries"
+)
+
+// Datasource UIDs as provisioned in grafana/datasources/datasource.yaml.
+const (
+	PrometheusUID = "prometheus"
+	LokiUID       = "loki"
+	TempoUID      = "tempo"
+)
+
+const (
+	service = "gophercon"
+	job     = `job="gophercon"`
+	refresh = "30s"
+
+	// schemaVersion is the dashboard schema of Grafana 10 and 11; without it
+	// Grafana would run every schema migration on the generated JSON.
+	schemaVersion = 39
+
+	fullWidth    = 24
+	halfWidth    = 12
+	quarterWidth = 6
+	panelHeight  = 8
+	statHeight   = 4
+)
+
+// All builds every dashboard.
+func All() ([]dashboard.Dashboard, error) {
+	var result []dashboard.Dashboard
+	for _, build := range []func() *dashboard.DashboardBuilder{Service, Runtime} {
+		d, err := build().Build()
+		if err != nil {
+			return nil, err
+		}
+		d.SchemaVersion = schemaVersion
+		numberPanels(&d)
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+// numberPanels gives every panel and row a unique ID, which Grafana needs to
+// link to a single panel.
+func numberPanels(d *dashboard.Dashboard) {
+	for i := range d.Panels {
+		id := uint32(i + 1) //nolint:gosec // a dashboard has few panels
+		switch {
+		case d.Panels[i].Panel != nil:
+			d.Panels[i].Panel.Id = &id
+		case d.Panels[i].RowPanel != nil:
+			d.Panels[i].RowPanel.Id = id
+		}
+	}
+}
+
+func newDashboard(uid, title string) *dashboard.DashboardBuilder {
+	return dashboard.NewDashboardBuilder(title).
+		Uid(uid).
+		Tags([]string{service, "generated"}).
+		Refresh(refresh).
+		Time("now-1h", "now").
+		Timezone(common.TimeZoneBrowser).
+		Tooltip(dashboard.DashboardCursorSyncCrosshair).
+		Readonly().
+		Link(dashboard.NewDashboardLinkBuilder("Calculator").
+			Type(dashboard.DashboardLinkTypeDashboards).
+			Tags([]string{service}).
+			AsDropdown(true).
+			KeepTime(true).
+			IncludeVars(true))
+}
+
+func datasource(kind, uid string) dashboard.DataSourceRef {
+	return dashboard.DataSourceRef{Type: cog.ToPtr(kind), Uid: cog.ToPtr(uid)}
+}
+
+func promQuery(expr, legend string) *prometheus.DataqueryBuilder {
+	return prometheus.NewDataqueryBuilder().
+		Expr(expr).
+		LegendFormat(legend).
+		Datasource(datasource("prometheus", PrometheusUID))
+}
+
+func graph(title, unit string, queries ...*prometheus.DataqueryBuilder) *timeseries.PanelBuilder {
+	panel := timeseries.NewPanelBuilder().
+		Title(title).
+		Unit(unit).
+		Min(0).
+		Span(halfWidth).
+		Height(panelHeight).
+		Datasource(datasource("prometheus", PrometheusUID)).
+		FillOpacity(10). //nolint:mnd // percent
+		Legend(common.NewVizLegendOptionsBuilder().
+			DisplayMode(common.LegendDisplayModeTable).
+			Placement(common.LegendPlacementBottom).
+			ShowLegend(true).
+			Calcs([]string{"mean", "max", "lastNotNull"}))
+	for _, query := range queries {
+		panel.WithTarget(query)
+	}
+
+	return panel
+}
+
+func single(title, unit, expr string) *stat.PanelBuilder {
+	return stat.NewPanelBuilder().
+		Title(title).
+		Unit(unit).
+		Span(quarterWidth).
+		Height(statHeight).
+		Datasource(datasource("prometheus", PrometheusUID)).
+		GraphMode(common.BigValueGraphModeArea).
+		ReduceOptions(common.NewReduceDataOptionsBuilder().Calcs([]string{"lastNotNull"})).
+		WithTarget(promQuery(expr, ""))
+}
+
+func logPanel(title, expr string) *logs.PanelBuilder {
+	return logs.NewPanelBuilder().
+		Title(title).
+		Span(fullWidth).
+		Height(panelHeight + panelHeight/2). //nolint:mnd // one and a half rows
+		Datasource(datasource("loki", LokiUID)).
+		ShowTime(true).
+		WrapLogMessage(true).
+		EnableLogDetails(true).
+		SortOrder(common.LogsSortOrderDescending).
+		WithTarget(loki.NewDataqueryBuilder().
+			Expr(expr).
+			Datasource(datasource("loki", LokiUID)))
+}
+
+func logVolume(title, expr, legend string) *timeseries.PanelBuilder {
+	return timeseries.NewPanelBuilder().
+		Title(title).
+		Span(halfWidth).
+		Height(panelHeight).
+		Datasource(datasource("loki", LokiUID)).
+		DrawStyle(common.GraphDrawStyleBars).
+		Stacking(common.NewStackingConfigBuilder().Mode(common.StackingModeNormal)).
+		WithTarget(loki.NewDataqueryBuilder().
+			Expr(expr).
+			LegendFormat(legend).
+			Datasource(datasource("loki", LokiUID)))
+}
+
+// traces lists the traces matching a TraceQL query. Each trace ID links to
+// the trace view in Tempo.
+func traces(title, traceQL string) *table.PanelBuilder {
+	return table.NewPanelBuilder().
+		Title(title).
+		Span(halfWidth).
+		Height(panelHeight).
+		Datasource(datasource("tempo", TempoUID)).
+		WithTarget(tempo.NewTempoQueryBuilder().
+			QueryType(string(tempo.TempoQueryTypeTraceql)).
+			Query(traceQL).
+			Limit(20). //nolint:mnd // traces per panel
+			TableType(tempo.SearchTableTypeTraces).
+			Datasource(datasource("tempo", TempoUID)))
+}
@@ -0,0 +1,67 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana-foundation-sdk/go/dashboard"
+)
+
+// Runtime returns the dashboard of the Go runtime and process metrics
+// exposed by the Prometheus client's default collectors and by
+// internal/runtimemetrics.
+func Runtime() *dashboard.DashboardBuilder {
+	sel := "{" + job + "}"
+
+	return newDashboard("gophercon-runtime", "Calculator Go runtime").
+		Description("Generated by cmd/dashboards. Edits made in Grafana are lost on restart.").
+		WithRow(dashboard.NewRowBuilder("Overview")).
+		WithPanel(single("Goroutines", "short", `sum(go_goroutines`+sel+`)`)).
+		WithPanel(single("Heap in use", "bytes", `sum(go_memstats_heap_inuse_bytes`+sel+`)`)).
+		WithPanel(single("Resident memory", "bytes", `sum(process_resident_memory_bytes`+sel+`)`)).
+		WithPanel(single("CPU", "percentunit", `sum(rate(process_cpu_seconds_total`+sel+`[$__rate_interval]))`)).
+		WithRow(dashboard.NewRowBuilder("Memory")).
+		WithPanel(graph("Heap", "bytes",
+			promQuery(`go_memstats_heap_alloc_bytes`+sel, "allocated"),
+			promQuery(`go_memstats_heap_inuse_bytes`+sel, "in use"),
+			promQuery(`go_memstats_heap_idle_bytes`+sel+` - go_memstats_heap_released_bytes`+sel, "idle, not released"),
+			promQuery(`go_memstats_next_gc_bytes`+sel, "next GC target"))).
+		WithPanel(graph("Allocation rate", "Bps",
+			promQuery(`rate(go_memstats_alloc_bytes_total`+sel+`[$__rate_interval])`, "bytes"))).
+		WithPanel(graph("Process memory", "bytes",
+			promQuery(`process_resident_memory_bytes`+sel, "resident"),
+			promQuery(`go_memstats_sys_bytes`+sel, "obtained from OS"),
+			promQuery(`go_memstats_stack_inuse_bytes`+sel, "stacks"))).
+		WithPanel(graph("Heap objects", "short",
+			promQuery(`go_memstats_heap_objects`+sel, "objects"),
+			promQuery(`rate(go_memory_allocations_total`+sel+`[$__rate_interval])`, "allocations / s"))).
+		WithRow(dashboard.NewRowBuilder("Scheduler and GC")).
+		WithPanel(graph("Goroutines and threads", "short",
+			promQuery(`go_goroutines`+sel, "goroutines"),
+			promQuery(`go_threads`+sel, "threads"),
+			promQuery(`go_sched_gomaxprocs_threads`+sel, "GOMAXPROCS"))).
+		WithPanel(graph("GC pause", "s",
+			promQuery(`histogram_quantile(0.99, sum by (le) (rate(go_gc_pause_duration_seconds_bucket`+sel+`[$__rate_interval])))`, "p99"),
+			promQuery(`go_gc_duration_seconds`+sel, "{{quantile}} since start"))).
+		WithPanel(graph("Scheduling latency", "s",
+			promQuery(`histogram_quantile(0.50, sum by (le) (rate(go_schedule_duration_seconds_bucket`+sel+`[$__rate_interval])))`, "p50"),
+			promQuery(`histogram_quantile(0.99, sum by (le) (rate(go_schedule_duration_seconds_bucket`+sel+`[$__rate_interval])))`, "p99"))).
+		WithPanel(graph("GC cycles", "ops",
+			promQuery(`rate(go_gc_duration_seconds_count`+sel+`[$__rate_interval])`, "cycles"))).
+		WithPanel(graph("CPU", "percentunit",
+			promQuery(`rate(process_cpu_seconds_total`+sel+`[$__rate_interval])`, "cpu"))).
+		WithRow(dashboard.NewRowBuilder("Process")).
+		WithPanel(graph("File descriptors", "short",
+			promQuery(`process_open_fds`+sel, "open"),
+			promQuery(`process_max_fds`+sel, "max"))).
+		WithPanel(graph("Network", "Bps",
+			promQuery(`rate(process_network_receive_bytes_total`+sel+`[$__rate_interval])`, "received"),
+			promQuery(`rate(process_network_transmit_bytes_total`+sel+`[$__rate_interval])`, "transmitted"))).
+		WithRow(dashboard.NewRowBuilder("Logging")).
+		WithPanel(graph("Log entries", "short",
+			promQuery(`sum(rate(promtail_sent_entries_total`+sel+`[$__rate_interval]))`, "sent"),
+			promQuery(`sum(rate(promtail_dropped_entries_total`+sel+`[$__rate_interval]))`, "dropped after retries"),
+			promQuery(`sum(rate(loki_queue_dropped_total`+sel+`[$__rate_interval]))`, "dropped, queue full"),
+			promQuery(`sum(rate(log_sampling_dropped_total`+sel+`[$__rate_interval]))`, "sampled out"))).
+		WithPanel(graph("Push retries", "short",
+			promQuery(`sum(rate(promtail_batch_retries_total`+sel+`[$__rate_interval]))`, "retries"))).
+		WithPanel(graph("Push duration", "s",
+			promQuery(`histogram_quantile(0.99, sum by (le) (rate(promtail_request_duration_seconds_bucket`+sel+`[$__rate_interval])))`, "p99")))
+}
@@ -0,0 +1,74 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana-foundation-sdk/go/cog"
+	"github.com/grafana/grafana-foundation-sdk/go/dashboard"
+)
+
+// Service returns the dashboard of the Calculator API: rate, errors and
+// duration per operation, SLO burn, the upstream pizza API called by
+// Multiply, logs and traces.
+func Service() *dashboard.DashboardBuilder {
+	const (
+		calls   = `grpc_server_call_duration_seconds_count{grpc_method=~"$operation"}`
+		buckets = `grpc_server_call_duration_seconds_bucket{grpc_method=~"$operation"}`
+		// upstream is the OTel HTTP client instrumentation of the calls to
+		// the pizza API; failed attempts that got no response are not
+		// recorded and show up as Multiply errors instead.
+		upstream = `http_client_duration_milliseconds`
+		logs     = `{service="gophercon"}`
+	)
+
+	return newDashboard("gophercon-service", "Calculator service").
+		Description("Generated by cmd/dashboards. Edits made in Grafana are lost on restart.").
+		WithVariable(dashboard.NewQueryVariableBuilder("operation").
+			Label("Operation").
+			Datasource(datasource("prometheus", PrometheusUID)).
+			Query(dashboard.StringOrAny{String: cog.ToPtr(`label_values(grpc_server_call_duration_seconds_count, grpc_method)`)}).
+			Refresh(dashboard.VariableRefreshOnTimeRangeChanged).
+			Multi(true).
+			IncludeAll(true).
+			AllValue(".*")).
+		WithRow(dashboard.NewRowBuilder("Overview")).
+		WithPanel(single("Requests / s", "reqps", `sum(rate(`+calls+`[$__rate_interval]))`)).
+		WithPanel(single("Error ratio", "percentunit",
+			`sum(rate(grpc_server_call_duration_seconds_count{grpc_method=~"$operation",grpc_status!="OK"}[$__rate_interval])) / sum(rate(`+calls+`[$__rate_interval]))`)).
+		WithPanel(single("p99 latency", "s", `histogram_quantile(0.99, sum by (le) (rate(`+buckets+`[$__rate_interval])))`)).
+		WithPanel(single("In flight", "short",
+			`sum(grpc_server_call_started_total{grpc_method=~"$operation"}) - sum(`+calls+`)`)).
+		WithRow(dashboard.NewRowBuilder("Rate, errors and duration")).
+		WithPanel(graph("Requests by operation", "reqps",
+			promQuery(`sum by (grpc_method) (rate(`+calls+`[$__rate_interval]))`, "{{grpc_method}}"))).
+		WithPanel(graph("Responses by status", "reqps",
+			promQuery(`sum by (grpc_status) (rate(`+calls+`[$__rate_interval]))`, "{{grpc_status}}"))).
+		WithPanel(graph("Error ratio by operation", "percentunit",
+			promQuery(`sum by (grpc_method) (rate(grpc_server_call_duration_seconds_count{grpc_method=~"$operation",grpc_status!="OK"}[$__rate_interval]))
+/
+sum by (grpc_method) (rate(`+calls+`[$__rate_interval]))`, "{{grpc_method}}"))).
+		WithPanel(graph("Latency by operation", "s",
+			promQuery(`histogram_quantile(0.50, sum by (grpc_method, le) (rate(`+buckets+`[$__rate_interval])))`, "p50 {{grpc_method}}"),
+			promQuery(`histogram_quantile(0.95, sum by (grpc_method, le) (rate(`+buckets+`[$__rate_interval])))`, "p95 {{grpc_method}}"),
+			promQuery(`histogram_quantile(0.99, sum by (grpc_method, le) (rate(`+buckets+`[$__rate_interval])))`, "p99 {{grpc_method}}"))).
+		WithRow(dashboard.NewRowBuilder("Service level objectives")).
+		WithPanel(graph("Error budget burn rate (1h)", "x",
+			promQuery(`slo:sli_error:ratio_rate1h / on (slo) slo:error_budget:ratio`, "{{slo}}"))).
+		WithPanel(graph("SLI over 1d", "percentunit",
+			promQuery(`1 - slo:sli_error:ratio_rate1d`, "{{slo}}"),
+			promQuery(`slo:objective:ratio`, "target {{slo}}"))).
+		WithRow(dashboard.NewRowBuilder("Upstream pizza API")).
+		WithPanel(graph("Upstream responses by status", "reqps",
+			promQuery(`sum by (http_status_code) (rate(`+upstream+`_count{`+job+`}[$__rate_interval]))`, "{{http_status_code}}"))).
+		WithPanel(graph("Upstream latency", "ms",
+			promQuery(`histogram_quantile(0.50, sum by (le) (rate(`+upstream+`_bucket{`+job+`}[$__rate_interval])))`, "p50"),
+			promQuery(`histogram_quantile(0.95, sum by (le) (rate(`+upstream+`_bucket{`+job+`}[$__rate_interval])))`, "p95"),
+			promQuery(`histogram_quantile(0.99, sum by (le) (rate(`+upstream+`_bucket{`+job+`}[$__rate_interval])))`, "p99"))).
+		WithPanel(graph("Multiply errors", "reqps",
+			promQuery(`sum by (grpc_status) (rate(grpc_server_call_duration_seconds_count{grpc_method="calculator.Calculator/Multiply",grpc_status!="OK"}[$__rate_interval]))`, "{{grpc_status}}"))).
+		WithPanel(logVolume("Upstream request failures", `sum(count_over_time(`+logs+` |= "quickpizza" | level="ERROR" [$__interval]))`, "failures")).
+		WithRow(dashboard.NewRowBuilder("Logs")).
+		WithPanel(logVolume("Log volume by level", `sum by (level) (count_over_time(`+logs+`[$__interval]))`, "{{level}}").Span(fullWidth)).
+		WithPanel(logPanel("Warnings and errors", logs+` | level=~"WARN|ERROR"`)).
+		WithRow(dashboard.NewRowBuilder("Traces")).
+		WithPanel(traces("Failed calls", `{resource.service.name="gophercon" && status=error}`)).
+		WithPanel(traces("Slow calls", `{resource.service.name="gophercon" && duration > 1s}`))
+}
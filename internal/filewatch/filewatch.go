@@ -0,0 +1,70 @@
+// Package filewatch notifies callers when files on disk change.
+//
+// It polls file metadata rather than relying on inotify so that it keeps
+// working with the symlink swaps Kubernetes uses for mounted secrets and
+// config maps.
+package filewatch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// DefaultInterval is the polling interval used when none is given.
+const DefaultInterval = 5 * time.Second
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// Watch polls the given paths every interval and calls onChange whenever
+// any of them is created, removed, or modified. It blocks until ctx is done.
+func Watch(ctx context.Context, interval time.Duration, onChange func(), paths ...string) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	states := snapshot(paths)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := snapshot(paths)
+			if changed(states, current) {
+				states = current
+				onChange()
+			}
+		}
+	}
+}
+
+func snapshot(paths []string) []fileState {
+	states := make([]fileState, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		states[i] = fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+	}
+
+	return states
+}
+
+func changed(previous, current []fileState) bool {
+	for i := range current {
+		if previous[i] != current[i] {
+			return true
+		}
+	}
+
+	return false
+}
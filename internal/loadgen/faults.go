@@ -0,0 +1,122 @@
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+type fault int
+
+const (
+	faultNone fault = iota
+	// faultDeadline gives the call a deadline it is unlikely to meet.
+	faultDeadline
+	// faultCancel cancels the call while it is in flight.
+	faultCancel
+	// faultOverflow sends operands that overflow int64 arithmetic.
+	faultOverflow
+	// faultReconnect makes the call on a fresh connection.
+	faultReconnect
+)
+
+const (
+	defaultFaultDeadline = 50 * time.Millisecond
+	defaultCancelAfter   = 10 * time.Millisecond
+)
+
+func (f fault) String() string {
+	switch f {
+	case faultDeadline:
+		return "deadline"
+	case faultCancel:
+		return "cancel"
+	case faultOverflow:
+		return "overflow"
+	case faultReconnect:
+		return "reconnect"
+	default:
+		return "none"
+	}
+}
+
+// Faults configures client-side fault injection. Each ratio is the share of
+// calls, between 0 and 1, that get the fault; zero disables it. A call gets
+// at most one fault.
+//
+// Calls with an injected fault are reported separately and do not count
+// against thresholds, since their failure is expected.
+type Faults struct {
+	// DeadlineRatio of calls get a deadline of Deadline.
+	DeadlineRatio float64       `yaml:"deadline_ratio"`
+	Deadline      time.Duration `yaml:"deadline"`
+	// CancelRatio of calls are cancelled CancelAfter after they start.
+	CancelRatio float64       `yaml:"cancel_ratio"`
+	CancelAfter time.Duration `yaml:"cancel_after"`
+	// OverflowRatio of calls use math.MaxInt64 for both operands.
+	OverflowRatio float64 `yaml:"overflow_ratio"`
+	// ReconnectRatio of calls open a new connection instead of reusing one.
+	ReconnectRatio float64 `yaml:"reconnect_ratio"`
+}
+
+// Validate checks that the ratios are within [0, 1] and add up to at most 1.
+func (f Faults) Validate() error {
+	var errs []error
+	total := 0.0
+	for name, ratio := range map[string]float64{
+		"deadline_ratio":  f.DeadlineRatio,
+		"cancel_ratio":    f.CancelRatio,
+		"overflow_ratio":  f.OverflowRatio,
+		"reconnect_ratio": f.ReconnectRatio,
+	} {
+		if ratio < 0 || ratio > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1", name))
+		}
+		total += ratio
+	}
+	if total > 1 {
+		errs = append(errs, errors.New("fault ratios add up to more than 1"))
+	}
+	if f.Deadline < 0 || f.CancelAfter < 0 {
+		errs = append(errs, errors.New("fault durations must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f Faults) draw() fault {
+	x := rand.Float64() //nolint:gosec // load generation does not need a CSPRNG
+	for _, candidate := range []struct {
+		fault fault
+		ratio float64
+	}{
+		{faultDeadline, f.DeadlineRatio},
+		{faultCancel, f.CancelRatio},
+		{faultOverflow, f.OverflowRatio},
+		{faultReconnect, f.ReconnectRatio},
+	} {
+		if x < candidate.ratio {
+			return candidate.fault
+		}
+		x -= candidate.ratio
+	}
+
+	return faultNone
+}
+
+func (f Faults) deadline() time.Duration {
+	if f.Deadline > 0 {
+		return f.Deadline
+	}
+
+	return defaultFaultDeadline
+}
+
+func (f Faults) cancelAfter() time.Duration {
+	if f.CancelAfter > 0 {
+		return f.CancelAfter
+	}
+
+	return defaultCancelAfter
+}
@@ -0,0 +1,324 @@
+// Package loadgen drives load against a Calculator server and reports
+// latency percentiles and errors.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+)
+
+// Model selects how arrivals are generated.
+type Model string
+
+const (
+	// ModelOpen issues calls at a fixed arrival rate regardless of how long
+	// they take, which exposes queueing when the server slows down.
+	ModelOpen Model = "open"
+	// ModelClosed runs a fixed number of virtual users, each issuing its next
+	// call only after the previous one completed plus a think time.
+	ModelClosed Model = "closed"
+)
+
+// Connector opens a connection to the server. The returned closer releases it.
+type Connector func() (calculator.Service, io.Closer, error)
+
+// Config describes a constant load.
+type Config struct {
+	Mix      Mix
+	A        Distribution
+	B        Distribution
+	Model    Model
+	Duration time.Duration
+
+	// Rate is the number of arrivals per second of the open model.
+	Rate float64
+	// StartRate is the arrival rate the open model ramps from when Ramp is set.
+	StartRate float64
+	// MaxInFlight bounds the concurrent calls of the open model; arrivals
+	// beyond it are dropped and counted.
+	MaxInFlight int
+
+	// VUs is the number of virtual users of the closed model.
+	VUs int
+	// StartVUs is the number of virtual users the closed model ramps from
+	// when Ramp is set.
+	StartVUs int
+	// ThinkTime is the pause of a virtual user between calls.
+	ThinkTime time.Duration
+
+	// Ramp changes the load linearly from StartRate or StartVUs to Rate or
+	// VUs over the duration instead of applying it at once.
+	Ramp bool
+
+	// ReuseConnections shares one connection between all calls. When false,
+	// every call opens and closes its own connection.
+	ReuseConnections bool
+	// Timeout bounds every call when positive.
+	Timeout time.Duration
+
+	// Faults are injected into a share of the calls.
+	Faults Faults
+}
+
+// Validate checks that the configuration describes a runnable load.
+func (c Config) Validate() error {
+	var errs []error
+	if err := c.Mix.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.A == nil || c.B == nil {
+		errs = append(errs, errors.New("operand distributions are required"))
+	}
+	if c.Duration <= 0 {
+		errs = append(errs, errors.New("duration must be positive"))
+	}
+	if err := c.Faults.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	switch c.Model {
+	case ModelOpen:
+		if c.Rate < 0 || c.StartRate < 0 || c.Rate+c.StartRate == 0 {
+			errs = append(errs, errors.New("open model requires a positive rate"))
+		}
+	case ModelClosed:
+		if c.VUs < 0 || c.StartVUs < 0 || c.VUs+c.StartVUs == 0 {
+			errs = append(errs, errors.New("closed model requires at least one virtual user"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown model %q", c.Model))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Runner generates load through a Connector.
+type Runner struct {
+	connect Connector
+
+	mu     sync.Mutex
+	shared calculator.Service
+	closer io.Closer
+}
+
+// NewRunner returns a Runner that opens connections with connect.
+func NewRunner(connect Connector) *Runner {
+	return &Runner{connect: connect}
+}
+
+// Close releases the shared connection, if any.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.shared, r.closer = nil, nil
+
+	return err
+}
+
+// Run applies the load described by cfg until its duration elapses or ctx is
+// done, recording every call in each of stats.
+func (r *Runner) Run(ctx context.Context, cfg Config, stats ...*Stats) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	p := newPicker(cfg.Mix)
+	rec := recorders(stats)
+	var wg sync.WaitGroup
+
+	switch cfg.Model {
+	case ModelOpen:
+		r.open(ctx, cfg, p, rec, &wg)
+	case ModelClosed:
+		r.closed(ctx, cfg, p, rec, &wg)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (r *Runner) open(ctx context.Context, cfg Config, p picker, rec recorders, wg *sync.WaitGroup) {
+	// idle bounds how long the loop sleeps before re-reading a ramping rate,
+	// so that a low starting rate does not delay arrivals once it has grown.
+	const idle = 10 * time.Millisecond
+
+	var inFlight atomic.Int64
+	var sent float64
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// Arrivals are scheduled against the number due since the start rather
+		// than the previous arrival so that timer jitter does not lower the rate.
+		elapsed := time.Since(start)
+		rate := cfg.ramp(cfg.StartRate, cfg.Rate, elapsed)
+		if rate <= 0 {
+			timer.Reset(idle)
+
+			continue
+		}
+		if due := cfg.arrivals(elapsed); sent > due {
+			wait := time.Duration((sent - due) / rate * float64(time.Second))
+			if cfg.Ramp {
+				wait = min(wait, idle)
+			}
+			timer.Reset(wait)
+
+			continue
+		}
+
+		sent++
+		if cfg.MaxInFlight > 0 && inFlight.Load() >= int64(cfg.MaxInFlight) {
+			rec.drop()
+		} else {
+			inFlight.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer inFlight.Add(-1)
+				r.call(ctx, cfg, p.pick(), rec)
+			}()
+		}
+		timer.Reset(0)
+	}
+}
+
+func (r *Runner) closed(ctx context.Context, cfg Config, p picker, rec recorders, wg *sync.WaitGroup) {
+	const idle = 100 * time.Millisecond
+
+	start := time.Now()
+	for vu := range max(cfg.VUs, cfg.StartVUs) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				// Virtual users beyond the current ramp target stay idle.
+				pause := cfg.ThinkTime
+				if active := cfg.ramp(float64(cfg.StartVUs), float64(cfg.VUs), time.Since(start)); float64(vu) < active {
+					r.call(ctx, cfg, p.pick(), rec)
+				} else {
+					pause = idle
+				}
+				if pause > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(pause):
+					}
+				}
+			}
+		}()
+	}
+}
+
+func (r *Runner) call(ctx context.Context, cfg Config, op string, rec recorders) {
+	fault := cfg.Faults.draw()
+
+	svc, release, err := r.service(cfg.ReuseConnections && fault != faultReconnect)
+	if err != nil {
+		rec.record(op, fault, 0, err)
+
+		return
+	}
+	defer release()
+
+	// Calls still running when the run ends are allowed to complete so that
+	// their latency is not cut short by the run deadline.
+	callCtx := context.WithoutCancel(ctx)
+	timeout := cfg.Timeout
+	if fault == faultDeadline {
+		timeout = cfg.Faults.deadline()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, timeout)
+		defer cancel()
+	}
+	if fault == faultCancel {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithCancel(callCtx)
+		defer cancel()
+		timer := time.AfterFunc(cfg.Faults.cancelAfter(), cancel)
+		defer timer.Stop()
+	}
+
+	a, b := cfg.A.Sample(), cfg.B.Sample()
+	if fault == faultOverflow {
+		a, b = math.MaxInt64, math.MaxInt64
+	}
+
+	start := time.Now()
+	_, err = Operations[op](svc, callCtx, a, b)
+	rec.record(op, fault, time.Since(start), err)
+}
+
+// ramp interpolates linearly from start to end over the duration, or returns
+// end when the load is not ramped.
+func (c Config) ramp(start, end float64, elapsed time.Duration) float64 {
+	if !c.Ramp {
+		return end
+	}
+
+	return start + (end-start)*min(float64(elapsed)/float64(c.Duration), 1)
+}
+
+// arrivals returns the number of open-model arrivals due after elapsed, the
+// integral of the possibly ramping rate.
+func (c Config) arrivals(elapsed time.Duration) float64 {
+	t := elapsed.Seconds()
+	if !c.Ramp {
+		return c.Rate * t
+	}
+	d := c.Duration.Seconds()
+	if t >= d {
+		return (c.StartRate+c.Rate)*d/2 + c.Rate*(t-d) //nolint:mnd // area of a trapezoid
+	}
+
+	return c.StartRate*t + (c.Rate-c.StartRate)*t*t/(2*d) //nolint:mnd // integral of a linear ramp
+}
+
+func (r *Runner) service(reuse bool) (calculator.Service, func(), error) {
+	if !reuse {
+		svc, closer, err := r.connect()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return svc, func() { _ = closer.Close() }, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.shared == nil {
+		svc, closer, err := r.connect()
+		if err != nil {
+			return nil, nil, err
+		}
+		r.shared, r.closer = svc, closer
+	}
+
+	return r.shared, func() {}, nil
+}
@@ -0,0 +1,372 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StageType describes how a stage moves the load to its target.
+type StageType string
+
+const (
+	// StageRamp changes the load linearly from the previous stage's target.
+	StageRamp StageType = "ramp"
+	// StageSteady holds the target load.
+	StageSteady StageType = "steady"
+	// StageSpike jumps to the target load at once, typically well above the
+	// surrounding stages and for a short time.
+	StageSpike StageType = "spike"
+	// StageSoak holds the target load for a long time to surface leaks and
+	// slow degradation.
+	StageSoak StageType = "soak"
+)
+
+// Scenario is a sequence of load stages with pass/fail thresholds, e.g.:
+//
+//	name: smoke
+//	model: open
+//	a: uniform:1,100
+//	b: uniform:1,10
+//	mix: {add: 1, subtract: 1, multiply: 1, divide: 1}
+//	stages:
+//	  - {name: warm-up, type: ramp, duration: 30s, rate: 50}
+//	  - {name: steady, type: steady, duration: 2m, rate: 50}
+//	  - name: spike
+//	    type: spike
+//	    duration: 20s
+//	    rate: 300
+//	    mix: {divide: 1}
+//	    faults: {deadline_ratio: 0.05, deadline: 20ms}
+//	thresholds:
+//	  - {operation: total, latency: {p95: 500ms, p99: 6s}, max_error_rate: 0.25}
+//
+// Stage mix and faults default to the scenario's.
+type Scenario struct {
+	Name             string        `yaml:"name"`
+	Model            Model         `yaml:"model"`
+	A                string        `yaml:"a"`
+	B                string        `yaml:"b"`
+	Mix              Mix           `yaml:"mix"`
+	Faults           Faults        `yaml:"faults"`
+	MaxInFlight      int           `yaml:"max_in_flight"`
+	ThinkTime        time.Duration `yaml:"think_time"`
+	Timeout          time.Duration `yaml:"timeout"`
+	ReuseConnections *bool         `yaml:"reuse_connections"`
+	Stages           []Stage       `yaml:"stages"`
+	Thresholds       []Threshold   `yaml:"thresholds"`
+}
+
+// Stage is one phase of a Scenario. Rate applies to the open model and VUs
+// to the closed model.
+type Stage struct {
+	Name       string        `yaml:"name"`
+	Type       StageType     `yaml:"type"`
+	Duration   time.Duration `yaml:"duration"`
+	Rate       float64       `yaml:"rate"`
+	VUs        int           `yaml:"vus"`
+	Mix        Mix           `yaml:"mix"`
+	Faults     *Faults       `yaml:"faults"`
+	Thresholds []Threshold   `yaml:"thresholds"`
+}
+
+// Threshold is a service level objective the load test must meet.
+// Latency keys are percentiles such as "p95" or "p99.9".
+type Threshold struct {
+	Operation     string                   `yaml:"operation"`
+	Latency       map[string]time.Duration `yaml:"latency"`
+	MaxErrorRate  *float64                 `yaml:"max_error_rate"`
+	MinThroughput float64                  `yaml:"min_throughput"`
+}
+
+// Check is the outcome of evaluating one threshold.
+type Check struct {
+	Stage     string
+	Operation string
+	Metric    string
+	Limit     string
+	Actual    string
+	Passed    bool
+}
+
+// StageResult holds the statistics of one stage.
+type StageResult struct {
+	Stage Stage
+	Stats *Stats
+}
+
+// Result is the outcome of a scenario run.
+type Result struct {
+	Scenario string
+	Stages   []StageResult
+	Total    *Stats
+	Checks   []Check
+}
+
+// Passed reports whether every threshold was met.
+func (r *Result) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoadScenario reads and validates a YAML scenario file.
+func LoadScenario(file string) (*Scenario, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var scenario Scenario
+	if err := decoder.Decode(&scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &scenario, nil
+}
+
+// Validate checks the scenario and fills in defaults.
+func (s *Scenario) Validate() error {
+	var errs []error
+
+	if s.Model == "" {
+		s.Model = ModelOpen
+	}
+	if s.A == "" {
+		s.A = "const:18"
+	}
+	if s.B == "" {
+		s.B = "const:3"
+	}
+	if len(s.Mix) == 0 {
+		s.Mix = Mix{"add": 1, "subtract": 1, "multiply": 1, "divide": 1}
+	}
+	if len(s.Stages) == 0 {
+		errs = append(errs, errors.New("scenario has no stages"))
+	}
+	errs = append(errs, validateThresholds("scenario", s.Thresholds)...)
+
+	for i := range s.Stages {
+		stage := &s.Stages[i]
+		if stage.Name == "" {
+			stage.Name = fmt.Sprintf("stage-%d", i+1)
+		}
+		switch stage.Type {
+		case StageRamp, StageSteady, StageSpike, StageSoak:
+		default:
+			errs = append(errs, fmt.Errorf("stage %s: unknown type %q", stage.Name, stage.Type))
+		}
+		if len(stage.Mix) == 0 {
+			stage.Mix = s.Mix
+		}
+		if stage.Faults == nil {
+			stage.Faults = &s.Faults
+		}
+		errs = append(errs, validateThresholds("stage "+stage.Name, stage.Thresholds)...)
+
+		if _, err := s.config(i); err != nil {
+			errs = append(errs, fmt.Errorf("stage %s: %w", stage.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run executes the stages in order and evaluates the thresholds.
+func (s *Scenario) Run(ctx context.Context, runner *Runner) (*Result, error) {
+	result := &Result{Scenario: s.Name, Total: NewStats()}
+
+	start := time.Now()
+	for i, stage := range s.Stages {
+		cfg, err := s.config(i)
+		if err != nil {
+			return nil, err
+		}
+
+		stats := NewStats()
+		stageStart := time.Now()
+		if err := runner.Run(ctx, cfg, stats, result.Total); err != nil {
+			return nil, fmt.Errorf("stage %s: %w", stage.Name, err)
+		}
+		stats.SetElapsed(time.Since(stageStart))
+		result.Stages = append(result.Stages, StageResult{Stage: stage, Stats: stats})
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	result.Total.SetElapsed(time.Since(start))
+
+	for _, sr := range result.Stages {
+		result.Checks = append(result.Checks, evaluate(sr.Stage.Name, sr.Stage.Thresholds, sr.Stats)...)
+	}
+	result.Checks = append(result.Checks, evaluate("scenario", s.Thresholds, result.Total)...)
+
+	return result, nil
+}
+
+func (s *Scenario) config(i int) (Config, error) {
+	stage := s.Stages[i]
+
+	a, err := ParseDistribution(s.A)
+	if err != nil {
+		return Config{}, err
+	}
+	b, err := ParseDistribution(s.B)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{
+		Mix:              stage.Mix,
+		A:                a,
+		B:                b,
+		Model:            s.Model,
+		Duration:         stage.Duration,
+		Rate:             stage.Rate,
+		MaxInFlight:      s.MaxInFlight,
+		VUs:              stage.VUs,
+		ThinkTime:        s.ThinkTime,
+		ReuseConnections: s.ReuseConnections == nil || *s.ReuseConnections,
+		Timeout:          s.Timeout,
+		Ramp:             stage.Type == StageRamp,
+	}
+	if stage.Faults != nil {
+		cfg.Faults = *stage.Faults
+	}
+	// A ramp starts from the previous stage's target, or from zero.
+	if cfg.Ramp && i > 0 {
+		cfg.StartRate = s.Stages[i-1].Rate
+		cfg.StartVUs = s.Stages[i-1].VUs
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func validateThresholds(scope string, thresholds []Threshold) []error {
+	var errs []error
+	for _, threshold := range thresholds {
+		for key := range threshold.Latency {
+			if _, err := parsePercentile(key); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", scope, err))
+			}
+		}
+		if r := threshold.MaxErrorRate; r != nil && (*r < 0 || *r > 1) {
+			errs = append(errs, fmt.Errorf("%s: max_error_rate must be between 0 and 1", scope))
+		}
+	}
+
+	return errs
+}
+
+func parsePercentile(key string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimPrefix(key, "p"), 64)
+	if err != nil || !strings.HasPrefix(key, "p") || p <= 0 || p > 100 {
+		return 0, fmt.Errorf("invalid percentile %q", key)
+	}
+
+	return p, nil
+}
+
+func evaluate(scope string, thresholds []Threshold, stats *Stats) []Check {
+	var checks []Check
+	for _, threshold := range thresholds {
+		op := strings.ToLower(threshold.Operation)
+		if op == "" {
+			op = totalKey
+		}
+		sum := stats.Summary(op)
+
+		for _, key := range slices.Sorted(maps.Keys(threshold.Latency)) {
+			p, _ := parsePercentile(key)
+			actual := stats.Percentile(op, p)
+			limit := threshold.Latency[key]
+			checks = append(checks, Check{
+				Stage: scope, Operation: op, Metric: key,
+				Limit: limit.String(), Actual: actual.String(),
+				Passed: sum.Requests > 0 && actual <= limit,
+			})
+		}
+		if threshold.MaxErrorRate != nil {
+			checks = append(checks, Check{
+				Stage: scope, Operation: op, Metric: "error_rate",
+				Limit:  strconv.FormatFloat(*threshold.MaxErrorRate, 'f', 4, 64),
+				Actual: strconv.FormatFloat(sum.ErrorRate, 'f', 4, 64),
+				Passed: sum.ErrorRate <= *threshold.MaxErrorRate,
+			})
+		}
+		if threshold.MinThroughput > 0 {
+			checks = append(checks, Check{
+				Stage: scope, Operation: op, Metric: "throughput",
+				Limit:  strconv.FormatFloat(threshold.MinThroughput, 'f', 1, 64),
+				Actual: strconv.FormatFloat(sum.Throughput, 'f', 1, 64),
+				Passed: sum.Throughput >= threshold.MinThroughput,
+			})
+		}
+	}
+
+	return checks
+}
+
+// Report writes the statistics of every stage, of the whole scenario, and
+// the threshold checks followed by the verdict.
+func (r *Result) Report(w io.Writer) error {
+	for _, sr := range r.Stages {
+		fmt.Fprintf(w, "== stage %s (%s, %s)\n", sr.Stage.Name, sr.Stage.Type, sr.Stage.Duration)
+		if err := sr.Stats.Report(w); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "== scenario %s\n", r.Scenario)
+	if err := r.Total.Report(w); err != nil {
+		return err
+	}
+
+	if len(r.Checks) > 0 {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+		fmt.Fprint(tw, "scope\toperation\tmetric\tlimit\tactual\tresult\n")
+		for _, check := range r.Checks {
+			verdict := "PASS"
+			if !check.Passed {
+				verdict = "FAIL"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", check.Stage, check.Operation, check.Metric, check.Limit, check.Actual, verdict)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	verdict := "PASS"
+	if !r.Passed() {
+		verdict = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "\nverdict: %s\n", verdict)
+
+	return err
+}
@@ -0,0 +1,223 @@
+package loadgen
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minLatency        = time.Microsecond
+	maxLatency        = time.Minute
+	significantDigits = 3
+	totalKey          = "total"
+)
+
+var percentiles = []float64{50, 90, 95, 99, 99.9}
+
+// Stats accumulates the latency and outcome of every call. It is safe for
+// concurrent use.
+type Stats struct {
+	mu         sync.Mutex
+	histograms map[string]*hdrhistogram.Histogram
+	requests   map[string]int64
+	errors     map[string]map[string]int64
+	injected   map[injectedKey]int64
+	dropped    int64
+	elapsed    time.Duration
+}
+
+type injectedKey struct {
+	op, fault, code string
+}
+
+// NewStats returns empty Stats.
+func NewStats() *Stats {
+	return &Stats{
+		histograms: map[string]*hdrhistogram.Histogram{},
+		requests:   map[string]int64{},
+		errors:     map[string]map[string]int64{},
+		injected:   map[injectedKey]int64{},
+	}
+}
+
+// Record adds the outcome of one call of operation op.
+func (s *Stats) Record(op string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range []string{op, totalKey} {
+		h, ok := s.histograms[key]
+		if !ok {
+			h = hdrhistogram.New(minLatency.Microseconds(), maxLatency.Microseconds(), significantDigits)
+			s.histograms[key] = h
+		}
+		_ = h.RecordValue(min(latency, maxLatency).Microseconds())
+		s.requests[key]++
+	}
+
+	if err != nil {
+		if s.errors[op] == nil {
+			s.errors[op] = map[string]int64{}
+		}
+		s.errors[op][status.Code(err).String()]++
+	}
+}
+
+// RecordInjected counts a call of operation op into which fault was
+// injected, keyed by the status code it ended with.
+func (s *Stats) RecordInjected(op, fault string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.injected[injectedKey{op: op, fault: fault, code: status.Code(err).String()}]++
+}
+
+// Drop counts an arrival that was skipped because too many calls were in flight.
+func (s *Stats) Drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropped++
+}
+
+// SetElapsed records the wall-clock duration of the run.
+func (s *Stats) SetElapsed(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.elapsed = elapsed
+}
+
+// Summary is a point-in-time digest of Stats for one operation or for all
+// operations ("total").
+type Summary struct {
+	Requests   int64
+	Errors     int64
+	ErrorRate  float64
+	Throughput float64
+	Latencies  map[float64]time.Duration
+	Max        time.Duration
+}
+
+// Summary returns the digest for op, or for every call when op is "total".
+func (s *Stats) Summary(op string) Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := Summary{Requests: s.requests[op], Latencies: map[float64]time.Duration{}}
+	for key, codes := range s.errors {
+		if key != op && op != totalKey {
+			continue
+		}
+		for _, n := range codes {
+			sum.Errors += n
+		}
+	}
+	if sum.Requests > 0 {
+		sum.ErrorRate = float64(sum.Errors) / float64(sum.Requests)
+	}
+	if s.elapsed > 0 {
+		sum.Throughput = float64(sum.Requests) / s.elapsed.Seconds()
+	}
+	if h, ok := s.histograms[op]; ok {
+		for _, p := range percentiles {
+			sum.Latencies[p] = time.Duration(h.ValueAtQuantile(p)) * time.Microsecond
+		}
+		sum.Max = time.Duration(h.Max()) * time.Microsecond
+	}
+
+	return sum
+}
+
+// Percentile returns the latency at percentile p, between 0 and 100, of
+// operation op or of every call when op is "total".
+func (s *Stats) Percentile(op string, p float64) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.histograms[op]
+	if !ok {
+		return 0
+	}
+
+	return time.Duration(h.ValueAtQuantile(p)) * time.Microsecond
+}
+
+// Report writes a latency table per operation followed by an error breakdown.
+func (s *Stats) Report(w io.Writer) error {
+	s.mu.Lock()
+	ops := slices.Sorted(maps.Keys(s.histograms))
+	errOps := slices.Sorted(maps.Keys(s.errors))
+	injected := slices.SortedFunc(maps.Keys(s.injected), func(a, b injectedKey) int {
+		return cmp.Or(cmp.Compare(a.op, b.op), cmp.Compare(a.fault, b.fault), cmp.Compare(a.code, b.code))
+	})
+	elapsed, dropped := s.elapsed, s.dropped
+	s.mu.Unlock()
+
+	ops = slices.DeleteFunc(ops, func(op string) bool { return op == totalKey })
+	ops = append(ops, totalKey)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight) //nolint:mnd // column padding
+	fmt.Fprintf(tw, "duration: %s\tdropped: %d\t\n\n", elapsed.Round(time.Millisecond), dropped)
+	fmt.Fprint(tw, "operation\trequests\terrors\trps\tp50\tp90\tp95\tp99\tp99.9\tmax\t\n")
+	for _, op := range ops {
+		sum := s.Summary(op)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t", op, sum.Requests, sum.Errors, sum.Throughput)
+		for _, p := range percentiles {
+			fmt.Fprintf(tw, "%s\t", sum.Latencies[p])
+		}
+		fmt.Fprintf(tw, "%s\t\n", sum.Max)
+	}
+
+	if len(errOps) > 0 {
+		fmt.Fprint(tw, "\noperation\tcode\tcount\t\n")
+		s.mu.Lock()
+		for _, op := range errOps {
+			for _, code := range slices.Sorted(maps.Keys(s.errors[op])) {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t\n", op, code, s.errors[op][code])
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	if len(injected) > 0 {
+		fmt.Fprint(tw, "\noperation\tinjected fault\tcode\tcount\t\n")
+		s.mu.Lock()
+		for _, key := range injected {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t\n", key.op, key.fault, key.code, s.injected[key])
+		}
+		s.mu.Unlock()
+	}
+
+	return tw.Flush()
+}
+
+// recorders fans the outcome of a call out to several Stats, e.g. those of
+// the current stage and of the whole scenario.
+type recorders []*Stats
+
+func (r recorders) record(op string, f fault, latency time.Duration, err error) {
+	for _, s := range r {
+		if f != faultNone {
+			s.RecordInjected(op, f.String(), err)
+
+			continue
+		}
+		s.Record(op, latency, err)
+	}
+}
+
+func (r recorders) drop() {
+	for _, s := range r {
+		s.Drop()
+	}
+}
@@ -0,0 +1,176 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rodneyosodo/gophercon/calculator"
+)
+
+// Operation invokes one Calculator method.
+type Operation func(calculator.Service, context.Context, int64, int64) (int64, error)
+
+// Operations maps the lower-case operation names to the methods they call.
+var Operations = map[string]Operation{
+	"add":      calculator.Service.Add,
+	"subtract": calculator.Service.Subtract,
+	"multiply": calculator.Service.Multiply,
+	"divide":   calculator.Service.Divide,
+}
+
+// Mix is the relative weight of each operation, e.g. {"add": 3, "divide": 1}.
+type Mix map[string]float64
+
+// ParseMix parses a comma separated list of operation=weight pairs.
+// An operation without a weight gets a weight of 1.
+func ParseMix(spec string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weight, found := strings.Cut(part, "=")
+		w := 1.0
+		if found {
+			var err error
+			if w, err = strconv.ParseFloat(weight, 64); err != nil {
+				return nil, fmt.Errorf("invalid weight for %s: %w", name, err)
+			}
+		}
+		mix[strings.ToLower(strings.TrimSpace(name))] = w
+	}
+
+	return mix, mix.Validate()
+}
+
+// Validate checks that the mix only names known operations and has a positive total weight.
+func (m Mix) Validate() error {
+	total := 0.0
+	for name, weight := range m {
+		if _, ok := Operations[name]; !ok {
+			return fmt.Errorf("unknown operation %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight for %s", name)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return fmt.Errorf("operation mix has no positive weights")
+	}
+
+	return nil
+}
+
+// picker draws operation names according to the weights of a Mix.
+type picker struct {
+	names      []string
+	cumulative []float64
+}
+
+func newPicker(m Mix) picker {
+	names := make([]string, 0, len(m))
+	for name, weight := range m {
+		if weight > 0 {
+			names = append(names, name)
+		}
+	}
+	// Sorted so that a given seed always yields the same sequence.
+	slices.Sort(names)
+
+	p := picker{names: names, cumulative: make([]float64, len(names))}
+	total := 0.0
+	for i, name := range names {
+		total += m[name]
+		p.cumulative[i] = total
+	}
+
+	return p
+}
+
+func (p picker) pick() string {
+	x := rand.Float64() * p.cumulative[len(p.cumulative)-1] //nolint:gosec // load generation does not need a CSPRNG
+	i, _ := slices.BinarySearch(p.cumulative, x)
+
+	return p.names[min(i, len(p.names)-1)]
+}
+
+// Distribution generates operands.
+type Distribution interface {
+	Sample() int64
+	String() string
+}
+
+// ParseDistribution parses one of:
+//
+//	const:<v>               always v
+//	uniform:<min>,<max>     uniform integers in [min, max]
+//	normal:<mean>,<stddev>  normally distributed, rounded
+//
+// A bare integer is shorthand for const.
+func ParseDistribution(spec string) (Distribution, error) {
+	kind, args, found := strings.Cut(spec, ":")
+	if !found {
+		kind, args = "const", spec
+	}
+
+	var values []float64
+	for _, arg := range strings.Split(args, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distribution %q: %w", spec, err)
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case kind == "const" && len(values) == 1:
+		return constant(int64(values[0])), nil
+	case kind == "uniform" && len(values) == 2 && values[0] <= values[1]: //nolint:mnd // min and max
+		return uniform{minValue: int64(values[0]), maxValue: int64(values[1])}, nil
+	case kind == "normal" && len(values) == 2 && values[1] >= 0: //nolint:mnd // mean and standard deviation
+		return normal{mean: values[0], stddev: values[1]}, nil
+	default:
+		return nil, fmt.Errorf("invalid distribution %q", spec)
+	}
+}
+
+type constant int64
+
+func (c constant) Sample() int64 {
+	return int64(c)
+}
+
+func (c constant) String() string {
+	return fmt.Sprintf("const:%d", int64(c))
+}
+
+type uniform struct {
+	minValue, maxValue int64
+}
+
+func (u uniform) Sample() int64 {
+	return u.minValue + rand.Int64N(u.maxValue-u.minValue+1) //nolint:gosec // load generation does not need a CSPRNG
+}
+
+func (u uniform) String() string {
+	return fmt.Sprintf("uniform:%d,%d", u.minValue, u.maxValue)
+}
+
+type normal struct {
+	mean, stddev float64
+}
+
+func (n normal) Sample() int64 {
+	return int64(math.Round(n.mean + rand.NormFloat64()*n.stddev)) //nolint:gosec // load generation does not need a CSPRNG
+}
+
+func (n normal) String() string {
+	return fmt.Sprintf("normal:%g,%g", n.mean, n.stddev)
+}
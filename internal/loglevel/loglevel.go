@@ -0,0 +1,193 @@
+// Package loglevel controls the minimum level of the server's loggers at
+// runtime, globally or per component.
+//
+// Every logger created from Levels shares one handler chain. A component
+// logger follows the global level until a level is set for it, and goes
+// back to the global level when that override is cleared.
+package loglevel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrUnknownComponent indicates a component no logger was created for.
+var ErrUnknownComponent = errors.New("unknown logging component")
+
+// Level is the level of one component.
+type Level struct {
+	Component string
+	Level     slog.Level
+	// Override reports whether the level was set for the component rather
+	// than inherited from the global level.
+	Override bool
+}
+
+type component struct {
+	level    slog.LevelVar
+	override atomic.Bool
+}
+
+// Levels creates component loggers and holds their levels. It is safe for
+// concurrent use.
+type Levels struct {
+	handler slog.Handler
+	global  slog.LevelVar
+
+	mu         sync.Mutex
+	components map[string]*component
+	// restore is the global level to go back to when debug is toggled off.
+	restore *slog.Level
+}
+
+// New returns Levels whose loggers write to handler at level unless
+// changed. handler must accept every level; filtering is done by Levels.
+func New(handler slog.Handler, level slog.Level) *Levels {
+	l := &Levels{handler: handler, components: map[string]*component{}}
+	l.global.Set(level)
+
+	return l
+}
+
+// Logger returns the logger of component, which is tagged with a component
+// attribute. The empty component is the root logger, which always follows
+// the global level.
+func (l *Levels) Logger(name string) *slog.Logger {
+	if name == "" {
+		return slog.New(&handler{inner: l.handler, global: &l.global})
+	}
+
+	l.mu.Lock()
+	c, ok := l.components[name]
+	if !ok {
+		c = &component{}
+		l.components[name] = c
+	}
+	l.mu.Unlock()
+
+	return slog.New(&handler{inner: l.handler, global: &l.global, component: c}).
+		With(slog.String("component", name))
+}
+
+// Set sets the level of component, or the global level when component is
+// empty.
+func (l *Levels) Set(name string, level slog.Level) error {
+	if name == "" {
+		l.mu.Lock()
+		l.restore = nil
+		l.mu.Unlock()
+		l.global.Set(level)
+
+		return nil
+	}
+
+	c, err := l.component(name)
+	if err != nil {
+		return err
+	}
+	c.level.Set(level)
+	c.override.Store(true)
+
+	return nil
+}
+
+// Reset makes component follow the global level again.
+func (l *Levels) Reset(name string) error {
+	c, err := l.component(name)
+	if err != nil {
+		return err
+	}
+	c.override.Store(false)
+
+	return nil
+}
+
+// Global returns the global level.
+func (l *Levels) Global() slog.Level {
+	return l.global.Level()
+}
+
+// List returns the effective level of every component, sorted by name.
+func (l *Levels) List() []Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	levels := make([]Level, 0, len(l.components))
+	for _, name := range slices.Sorted(maps.Keys(l.components)) {
+		c := l.components[name]
+		level := Level{Component: name, Level: l.global.Level()}
+		if c.override.Load() {
+			level.Level = c.level.Level()
+			level.Override = true
+		}
+		levels = append(levels, level)
+	}
+
+	return levels
+}
+
+// ToggleDebug switches the global level to debug, or back to the level it
+// had before when it is already toggled. It returns the new global level.
+func (l *Levels) ToggleDebug() slog.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.restore != nil {
+		l.global.Set(*l.restore)
+		l.restore = nil
+
+		return l.global.Level()
+	}
+
+	previous := l.global.Level()
+	l.restore = &previous
+	l.global.Set(slog.LevelDebug)
+
+	return slog.LevelDebug
+}
+
+func (l *Levels) component(name string) (*component, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.components[name]
+	if !ok {
+		return nil, ErrUnknownComponent
+	}
+
+	return c, nil
+}
+
+var _ slog.Handler = (*handler)(nil)
+
+type handler struct {
+	inner     slog.Handler
+	global    *slog.LevelVar
+	component *component
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	minimum := h.global.Level()
+	if h.component != nil && h.component.override.Load() {
+		minimum = h.component.level.Level()
+	}
+
+	return level >= minimum && h.inner.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{inner: h.inner.WithAttrs(attrs), global: h.global, component: h.component}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{inner: h.inner.WithGroup(name), global: h.global, component: h.component}
+}
@@ -0,0 +1,179 @@
+// Package logsample thins out repetitive log records.
+//
+// Records are grouped by message and by the value of their "operation"
+// attribute. Within each interval the first records of a group are kept and
+// then one in every Thereafter. Warnings, errors and records whose
+// "duration" attribute reaches the slow threshold are always kept, so that
+// sampling only reduces the volume of successful, fast calls.
+package logsample
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	scope = "github.com/rodneyosodo/gophercon/internal/logsample"
+
+	operationKey = "operation"
+	durationKey  = "duration"
+)
+
+// Config configures sampling. Sampling is disabled when Interval is zero.
+type Config struct {
+	Interval time.Duration
+	// First is the number of records of a group kept in each interval.
+	First int
+	// Thereafter keeps one in every Thereafter records of a group once First
+	// is reached. Zero drops them all.
+	Thereafter int
+	// SlowThreshold keeps records whose duration reaches it. Zero disables
+	// the check.
+	SlowThreshold time.Duration
+}
+
+// Sampler holds the sampling state shared by every handler derived from it.
+// Its configuration can be changed while records are logged.
+type Sampler struct {
+	dropped metric.Int64Counter
+
+	mu     sync.Mutex
+	cfg    Config
+	window time.Time
+	counts map[string]int
+}
+
+// NewSampler returns a Sampler counting dropped records on provider.
+func NewSampler(cfg Config, provider metric.MeterProvider) (*Sampler, error) {
+	dropped, err := provider.Meter(scope).Int64Counter("log.sampling.dropped",
+		metric.WithUnit("{record}"), metric.WithDescription("Log records dropped by sampling."))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sampler{dropped: dropped, cfg: cfg, counts: map[string]int{}}, nil
+}
+
+// Set replaces the configuration and starts a new interval.
+func (s *Sampler) Set(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cfg = cfg
+	s.window = time.Time{}
+	clear(s.counts)
+}
+
+// Handler returns inner with sampling applied.
+func (s *Sampler) Handler(inner slog.Handler) slog.Handler {
+	return &handler{inner: inner, sampler: s}
+}
+
+// keep reports whether the record of key logged at now is kept.
+func (s *Sampler) keep(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg.Interval <= 0 {
+		return true
+	}
+	if now.Sub(s.window) >= s.cfg.Interval {
+		s.window = now
+		clear(s.counts)
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.cfg.First {
+		return true
+	}
+
+	return s.cfg.Thereafter > 0 && (n-s.cfg.First)%s.cfg.Thereafter == 0
+}
+
+func (s *Sampler) slow(v slog.Value) bool {
+	s.mu.Lock()
+	threshold := s.cfg.SlowThreshold
+	s.mu.Unlock()
+
+	if threshold <= 0 {
+		return false
+	}
+
+	var d time.Duration
+	switch v.Kind() {
+	case slog.KindDuration:
+		d = v.Duration()
+	case slog.KindString:
+		parsed, err := time.ParseDuration(v.String())
+		if err != nil {
+			return false
+		}
+		d = parsed
+	default:
+		return false
+	}
+
+	return d >= threshold
+}
+
+var _ slog.Handler = (*handler)(nil)
+
+type handler struct {
+	inner   slog.Handler
+	sampler *Sampler
+	// operation is the operation attribute added with WithAttrs.
+	operation string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		return h.inner.Handle(ctx, r)
+	}
+
+	operation, slow := h.operation, false
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case operationKey:
+			operation = a.Value.String()
+		case durationKey:
+			slow = h.sampler.slow(a.Value.Resolve())
+		}
+
+		return !slow
+	})
+
+	if slow || h.sampler.keep(operation+"\x00"+r.Message, r.Time) {
+		return h.inner.Handle(ctx, r)
+	}
+	h.sampler.dropped.Add(ctx, 1)
+
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == operationKey {
+			next.operation = a.Value.String()
+		}
+	}
+
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.inner = h.inner.WithGroup(name)
+
+	return &next
+}
@@ -0,0 +1,242 @@
+package logsample
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rodneyosodo/gophercon/internal/redact"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// capture is a handler keeping the messages of the records it handles.
+type capture struct {
+	mu       *sync.Mutex
+	messages *[]string
+}
+
+func newCapture() capture {
+	return capture{mu: &sync.Mutex{}, messages: &[]string{}}
+}
+
+func (c capture) Enabled(context.Context, slog.Level) bool { return true }
+
+func (c capture) Handle(_ context.Context, r slog.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.messages = append(*c.messages, r.Message)
+
+	return nil
+}
+
+func (c capture) WithAttrs([]slog.Attr) slog.Handler { return c }
+func (c capture) WithGroup(string) slog.Handler      { return c }
+
+func (c capture) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(*c.messages)
+}
+
+func newSampler(t *testing.T, cfg Config) *Sampler {
+	t.Helper()
+
+	sampler, err := NewSampler(cfg, noop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("NewSampler() error = %v", err)
+	}
+
+	return sampler
+}
+
+func TestSamplerWithRedaction(t *testing.T) {
+	cases := []struct {
+		name   string
+		action redact.Action
+	}{
+		{name: "allow", action: redact.Allow},
+		{name: "hash", action: redact.Hash},
+		{name: "mask", action: redact.Mask},
+		{name: "drop", action: redact.Drop},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Only slow calls are kept once the first record is logged.
+			sampler := newSampler(t, Config{Interval: time.Hour, First: 1, SlowThreshold: 100 * time.Millisecond})
+			out := newCapture()
+			redactor := redact.New(redact.Policy{Default: tc.action})
+			// As in the server, redaction wraps the sampled handler.
+			logger := slog.New(redactor.Handler(sampler.Handler(out)))
+
+			for _, d := range []time.Duration{time.Millisecond, time.Millisecond, time.Second, 200 * time.Millisecond} {
+				logger.Info("Call", slog.String("operation", "Add"), slog.Duration("duration", d))
+			}
+			if got := out.count(); got != 3 {
+				t.Errorf("kept %d records, want the first one and 2 slow ones", got)
+			}
+		})
+	}
+}
+
+func TestSamplerKeep(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+		// offsets are the times of the records, from the start.
+		offsets []time.Duration
+		want    []bool
+	}{
+		{
+			name:    "disabled",
+			cfg:     Config{First: 1},
+			offsets: []time.Duration{0, 0, 0},
+			want:    []bool{true, true, true},
+		},
+		{
+			name:    "first then drop",
+			cfg:     Config{Interval: time.Second, First: 2},
+			offsets: []time.Duration{0, 0, 0, 0},
+			want:    []bool{true, true, false, false},
+		},
+		{
+			name:    "first then one in thereafter",
+			cfg:     Config{Interval: time.Second, First: 1, Thereafter: 3},
+			offsets: []time.Duration{0, 0, 0, 0, 0, 0, 0},
+			want:    []bool{true, false, false, true, false, false, true},
+		},
+		{
+			name:    "thereafter only",
+			cfg:     Config{Interval: time.Second, Thereafter: 2},
+			offsets: []time.Duration{0, 0, 0, 0},
+			want:    []bool{false, true, false, true},
+		},
+		{
+			name:    "new interval resets the counts",
+			cfg:     Config{Interval: time.Second, First: 1},
+			offsets: []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second},
+			want:    []bool{true, false, true, false, true},
+		},
+	}
+
+	start := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := newSampler(t, tc.cfg)
+			for i, offset := range tc.offsets {
+				if got := sampler.keep("Add", start.Add(offset)); got != tc.want[i] {
+					t.Errorf("record %d: keep() = %v, want %v", i+1, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSamplerSlow(t *testing.T) {
+	cases := []struct {
+		name      string
+		threshold time.Duration
+		value     slog.Value
+		want      bool
+	}{
+		{name: "below", threshold: time.Second, value: slog.DurationValue(999 * time.Millisecond)},
+		{name: "at", threshold: time.Second, value: slog.DurationValue(time.Second), want: true},
+		{name: "above", threshold: time.Second, value: slog.DurationValue(2 * time.Second), want: true},
+		{name: "string", threshold: time.Second, value: slog.StringValue("1.5s"), want: true},
+		{name: "invalid string", threshold: time.Second, value: slog.StringValue("slow")},
+		{name: "other kind", threshold: time.Second, value: slog.Int64Value(int64(2 * time.Second))},
+		{name: "disabled", value: slog.DurationValue(time.Hour)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := newSampler(t, Config{SlowThreshold: tc.threshold})
+			if got := sampler.slow(tc.value); got != tc.want {
+				t.Errorf("slow(%v) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSamplerHandler(t *testing.T) {
+	type record struct {
+		level     slog.Level
+		operation string
+		duration  time.Duration
+	}
+
+	cases := []struct {
+		name    string
+		records []record
+		want    int
+	}{
+		{
+			name:    "repeated records are sampled",
+			records: []record{{operation: "Add"}, {operation: "Add"}, {operation: "Add"}},
+			want:    1,
+		},
+		{
+			name:    "operations are sampled apart",
+			records: []record{{operation: "Add"}, {operation: "Add"}, {operation: "Divide"}},
+			want:    2,
+		},
+		{
+			name:    "warnings and errors are kept",
+			records: []record{{operation: "Add"}, {level: slog.LevelWarn, operation: "Add"}, {level: slog.LevelError, operation: "Add"}},
+			want:    3,
+		},
+		{
+			name:    "slow calls are kept",
+			records: []record{{operation: "Add"}, {operation: "Add", duration: time.Second}, {operation: "Add", duration: time.Millisecond}},
+			want:    2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := newSampler(t, Config{Interval: time.Hour, First: 1, SlowThreshold: 100 * time.Millisecond})
+			out := newCapture()
+			logger := slog.New(sampler.Handler(out))
+
+			for _, r := range tc.records {
+				logger.Log(context.Background(), r.level, "Call", slog.String("operation", r.operation), slog.Duration("duration", r.duration))
+			}
+			if got := out.count(); got != tc.want {
+				t.Errorf("kept %d records, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSamplerHandlerWithAttrs(t *testing.T) {
+	sampler := newSampler(t, Config{Interval: time.Hour, First: 1})
+	out := newCapture()
+	add := slog.New(sampler.Handler(out)).With(slog.String("operation", "Add"))
+	divide := slog.New(sampler.Handler(out)).With(slog.String("operation", "Divide"))
+
+	add.Info("Call")
+	add.Info("Call")
+	divide.Info("Call")
+	if got := out.count(); got != 2 {
+		t.Errorf("kept %d records, want one per operation", got)
+	}
+}
+
+func TestSamplerSet(t *testing.T) {
+	sampler := newSampler(t, Config{Interval: time.Hour, First: 1})
+	out := newCapture()
+	logger := slog.New(sampler.Handler(out))
+
+	logger.Info("Call")
+	logger.Info("Call")
+	sampler.Set(Config{Interval: time.Hour, First: 2})
+	logger.Info("Call")
+	logger.Info("Call")
+	logger.Info("Call")
+	if got := out.count(); got != 3 {
+		t.Errorf("kept %d records, want 3", got)
+	}
+}
@@ -0,0 +1,337 @@
+// Package lokilog ships slog records to Grafana Loki.
+//
+// Only the promoted attributes become stream labels; the message and every
+// other attribute are written as a JSON log line, so that high-cardinality
+// values such as durations or operands do not create streams.
+//
+// Records are queued and pushed by a background goroutine, so logging never
+// waits on Loki. Records are dropped when the queue is full and counted on
+// loki.queue.dropped. The Loki client registers its own promtail_* metrics,
+// including promtail_dropped_entries_total and promtail_batch_retries_total,
+// on the Prometheus default registry.
+package lokilog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki-client-go/loki"
+	"github.com/grafana/loki-client-go/pkg/labelutil"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const scope = "github.com/rodneyosodo/gophercon/internal/lokilog"
+
+// ErrInvalidLabel indicates a static or promoted label name Loki rejects.
+var ErrInvalidLabel = errors.New("invalid loki label name")
+
+// Config configures the Loki client.
+type Config struct {
+	// TenantID is sent as X-Scope-OrgID. Empty means single tenant.
+	TenantID string
+	// Labels are added to every stream, e.g. env, region or version.
+	Labels map[string]string
+	// PromotedLabels are the slog attributes used as stream labels. The
+	// "level" label is the record level.
+	PromotedLabels []string
+	BatchSize      int
+	BatchWait      time.Duration
+	Timeout        time.Duration
+	MaxRetries     int
+	// QueueSize is the number of records buffered while Loki is slow.
+	QueueSize int
+	// Username and Password enable basic authentication.
+	Username string
+	Password string
+	// BearerToken or BearerTokenFile enable bearer authentication.
+	BearerToken     string
+	BearerTokenFile string
+	// CAFile, CertFile and KeyFile configure TLS to Loki.
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Validate checks the label names.
+func (c Config) Validate() error {
+	var errs []error
+	for name := range c.Labels {
+		if !model.LabelName(name).IsValid() {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrInvalidLabel, name))
+		}
+	}
+	for _, name := range c.PromotedLabels {
+		if !model.LabelName(sanitize(name)).IsValid() {
+			errs = append(errs, fmt.Errorf("%w: %q", ErrInvalidLabel, name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) clientConfig(url string) (loki.Config, error) {
+	cfg, err := loki.NewDefaultConfig(url)
+	if err != nil {
+		return loki.Config{}, err
+	}
+	cfg.TenantID = c.TenantID
+	if c.BatchSize > 0 {
+		cfg.BatchSize = c.BatchSize
+	}
+	if c.BatchWait > 0 {
+		cfg.BatchWait = c.BatchWait
+	}
+	if c.Timeout > 0 {
+		cfg.Timeout = c.Timeout
+	}
+	if c.MaxRetries > 0 {
+		cfg.BackoffConfig.MaxRetries = c.MaxRetries
+	}
+	if len(c.Labels) > 0 {
+		labels := model.LabelSet{}
+		for name, value := range c.Labels {
+			labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		cfg.ExternalLabels = labelutil.LabelSet{LabelSet: labels}
+	}
+
+	if c.Username != "" || c.Password != "" {
+		cfg.Client.BasicAuth = &promconfig.BasicAuth{Username: c.Username, Password: promconfig.Secret(c.Password)}
+	}
+	if c.BearerToken != "" || c.BearerTokenFile != "" {
+		cfg.Client.Authorization = &promconfig.Authorization{
+			Type:            "Bearer",
+			Credentials:     promconfig.Secret(c.BearerToken),
+			CredentialsFile: c.BearerTokenFile,
+		}
+	}
+	cfg.Client.TLSConfig = promconfig.TLSConfig{
+		CAFile:             c.CAFile,
+		CertFile:           c.CertFile,
+		KeyFile:            c.KeyFile,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	return cfg, nil
+}
+
+type entry struct {
+	labels model.LabelSet
+	time   time.Time
+	line   string
+}
+
+// Sink owns the Loki client and the queue shared by every Handler derived
+// from it.
+type Sink struct {
+	client   *loki.Client
+	promoted map[string]bool
+	queue    chan entry
+	dropped  metric.Int64Counter
+	done     chan struct{}
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// New returns a Sink pushing to the Loki push API at url.
+func New(url string, cfg Config, provider metric.MeterProvider) (*Sink, error) {
+	clientConfig, err := cfg.clientConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loki config: %w", err)
+	}
+	client, err := loki.New(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create loki client: %w", err)
+	}
+
+	dropped, err := provider.Meter(scope).Int64Counter("loki.queue.dropped",
+		metric.WithUnit("{record}"), metric.WithDescription("Log records dropped because the Loki queue was full."))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sink{
+		client:   client,
+		promoted: map[string]bool{},
+		queue:    make(chan entry, max(cfg.QueueSize, 1)),
+		dropped:  dropped,
+		done:     make(chan struct{}),
+	}
+	for _, name := range cfg.PromotedLabels {
+		s.promoted[name] = true
+	}
+	go s.run()
+
+	return s, nil
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+	for e := range s.queue {
+		_ = s.client.Handle(e.labels, e.time, e.line) // never fails
+	}
+}
+
+// Stop flushes the queued records to Loki. Records logged after Stop are
+// dropped.
+func (s *Sink) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+
+		return
+	}
+	s.stopped = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	<-s.done
+	s.client.Stop()
+}
+
+func (s *Sink) enqueue(e entry) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.stopped {
+		s.dropped.Add(context.Background(), 1)
+
+		return
+	}
+	select {
+	case s.queue <- e:
+	default:
+		s.dropped.Add(context.Background(), 1)
+	}
+}
+
+// Handler returns a slog.Handler writing to the sink at level and above.
+func (s *Sink) Handler(level slog.Leveler) slog.Handler {
+	return &handler{sink: s, level: level}
+}
+
+var _ slog.Handler = (*handler)(nil)
+
+type handler struct {
+	sink  *Sink
+	level slog.Leveler
+	// attrs are the attributes added with WithAttrs, with their keys
+	// prefixed by the open groups.
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	attrs := slices.Clone(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = flatten(attrs, h.groups, a)
+
+		return true
+	})
+
+	labels := model.LabelSet{}
+	if h.sink.promoted["level"] {
+		labels["level"] = model.LabelValue(r.Level.String())
+	}
+
+	line := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	for _, a := range attrs {
+		if h.sink.promoted[a.Key] {
+			labels[model.LabelName(sanitize(a.Key))] = model.LabelValue(a.Value.String())
+
+			continue
+		}
+		line.AddAttrs(a)
+	}
+
+	var buf bytes.Buffer
+	err := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// The timestamp is the entry's and the level is a label.
+			if len(groups) == 0 && (a.Key == slog.TimeKey || (a.Key == slog.LevelKey && h.sink.promoted["level"])) {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}).Handle(context.Background(), line)
+	if err != nil {
+		return err
+	}
+
+	h.sink.enqueue(entry{labels: labels, time: r.Time, line: strings.TrimSuffix(buf.String(), "\n")})
+
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		next.attrs = flatten(next.attrs, h.groups, a)
+	}
+
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.groups = append(slices.Clone(h.groups), name)
+
+	return &next
+}
+
+// flatten appends a to attrs with its key prefixed by groups. Group values
+// are flattened into one attribute per member.
+func flatten(attrs []slog.Attr, groups []string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(slices.Clone(groups), a.Key)
+		}
+		for _, member := range a.Value.Group() {
+			attrs = flatten(attrs, groups, member)
+		}
+
+		return attrs
+	}
+
+	if len(groups) > 0 {
+		a.Key = strings.Join(groups, ".") + "." + a.Key
+	}
+
+	return append(attrs, a)
+}
+
+// sanitize maps an attribute key to a Loki label name.
+func sanitize(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, key)
+}
@@ -0,0 +1,260 @@
+// Package redact applies a per-field policy to the values the server records
+// in logs, spans and audit records.
+//
+// Fields are matched by key, e.g. "a", "b", "result", "principal" or
+// "error". Each is allowed as is, replaced by a keyed hash so that equal
+// values can still be correlated, masked, or dropped. The "duration" field
+// is always allowed: it carries no caller data, and log sampling reads it
+// to keep slow calls.
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Action is what is done with the value of a field.
+type Action string
+
+const (
+	// Allow records the value as is.
+	Allow Action = "allow"
+	// Hash records a keyed SHA-256 hash of the value.
+	Hash Action = "hash"
+	// Mask records the value with all but its last characters replaced.
+	Mask Action = "mask"
+	// Drop does not record the field.
+	Drop Action = "drop"
+)
+
+const (
+	hashLength  = 16
+	hashKeySize = 32
+	maskChar    = "*"
+	// Mask leaves one in maskVisible characters visible, at the end.
+	maskVisible = 4
+)
+
+// ErrInvalidAction indicates an action other than allow, hash, mask or drop.
+var ErrInvalidAction = errors.New("invalid redaction action")
+
+// Policy maps fields to actions, e.g.:
+//
+//	default: allow
+//	fields:
+//	  principal: hash
+//	  a: mask
+//	  b: mask
+type Policy struct {
+	// Default applies to fields not listed in Fields. Empty means Allow.
+	Default Action
+	Fields  map[string]Action
+	// HashKey keys the hashes. When empty a random key is generated, so
+	// hashes only correlate within one process.
+	HashKey string
+}
+
+// Validate checks the actions.
+func (p Policy) Validate() error {
+	var errs []error
+	if !valid(p.Default) {
+		errs = append(errs, fmt.Errorf("%w: default %q", ErrInvalidAction, p.Default))
+	}
+	for field, action := range p.Fields {
+		if !valid(action) {
+			errs = append(errs, fmt.Errorf("%w: %s %q", ErrInvalidAction, field, action))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func valid(action Action) bool {
+	switch action {
+	case "", Allow, Hash, Mask, Drop:
+		return true
+	default:
+		return false
+	}
+}
+
+// durationKey is the field that is always allowed.
+const durationKey = "duration"
+
+func (p Policy) action(key string) Action {
+	if key == durationKey {
+		return Allow
+	}
+	if action, ok := p.Fields[key]; ok && action != "" {
+		return action
+	}
+	if p.Default == "" {
+		return Allow
+	}
+
+	return p.Default
+}
+
+type compiled struct {
+	policy Policy
+	key    []byte
+}
+
+// Redactor applies a Policy. The policy can be replaced while values are
+// redacted. A nil Redactor allows every field.
+type Redactor struct {
+	randomKey []byte
+	current   atomic.Pointer[compiled]
+}
+
+// New returns a Redactor applying policy.
+func New(policy Policy) *Redactor {
+	r := &Redactor{randomKey: make([]byte, hashKeySize)}
+	_, _ = rand.Read(r.randomKey) // never fails
+	r.Set(policy)
+
+	return r
+}
+
+// Set replaces the policy. Attributes already added to a logger with
+// WithAttrs keep the redaction they were given.
+func (r *Redactor) Set(policy Policy) {
+	c := &compiled{policy: policy, key: r.randomKey}
+	if policy.HashKey != "" {
+		c.key = []byte(policy.HashKey)
+	}
+	r.current.Store(c)
+}
+
+// String applies the policy to the string form of a field. It returns false
+// when the field is dropped.
+func (r *Redactor) String(key, value string) (string, bool) {
+	if r == nil {
+		return value, true
+	}
+	c := r.current.Load()
+
+	switch c.policy.action(key) {
+	case Drop:
+		return "", false
+	case Hash:
+		mac := hmac.New(sha256.New, c.key)
+		mac.Write([]byte(value))
+
+		return hex.EncodeToString(mac.Sum(nil))[:hashLength], true
+	case Mask:
+		runes := []rune(value)
+		visible := len(runes) / maskVisible
+
+		return strings.Repeat(maskChar, len(runes)-visible) + string(runes[len(runes)-visible:]), true
+	default:
+		return value, true
+	}
+}
+
+// Allowed reports whether the policy records the field as is.
+func (r *Redactor) Allowed(key string) bool {
+	return r == nil || r.current.Load().policy.action(key) == Allow
+}
+
+// Attr applies the policy to a log attribute. Members of a group are
+// matched by their own key. It returns false when the attribute is dropped.
+func (r *Redactor) Attr(a slog.Attr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		members := make([]any, 0, len(a.Value.Group()))
+		for _, member := range a.Value.Group() {
+			if member, ok := r.Attr(member); ok {
+				members = append(members, member)
+			}
+		}
+
+		return slog.Group(a.Key, members...), true
+	}
+	if r.Allowed(a.Key) {
+		return a, true
+	}
+
+	value, ok := r.String(a.Key, a.Value.String())
+
+	return slog.String(a.Key, value), ok
+}
+
+// KeyValue applies the policy to a span attribute. It returns false when
+// the attribute is dropped.
+func (r *Redactor) KeyValue(kv attribute.KeyValue) (attribute.KeyValue, bool) {
+	if r.Allowed(string(kv.Key)) {
+		return kv, true
+	}
+
+	value, ok := r.String(string(kv.Key), kv.Value.Emit())
+
+	return kv.Key.String(value), ok
+}
+
+// KeyValues applies the policy to span attributes.
+func (r *Redactor) KeyValues(kvs ...attribute.KeyValue) []attribute.KeyValue {
+	result := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		if kv, ok := r.KeyValue(kv); ok {
+			result = append(result, kv)
+		}
+	}
+
+	return result
+}
+
+// Handler returns inner with the policy applied to every attribute logged
+// through it, including those added with WithAttrs.
+func (r *Redactor) Handler(inner slog.Handler) slog.Handler {
+	return &handler{inner: inner, redactor: r}
+}
+
+var _ slog.Handler = (*handler)(nil)
+
+type handler struct {
+	inner    slog.Handler
+	redactor *Redactor
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := h.redactor.Attr(a); ok {
+			redacted.AddAttrs(a)
+		}
+
+		return true
+	})
+
+	return h.inner.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := h.redactor.Attr(a); ok {
+			redacted = append(redacted, a)
+		}
+	}
+
+	return &handler{inner: h.inner.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{inner: h.inner.WithGroup(name), redactor: h.redactor}
+}
@@ -0,0 +1,261 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// hashOf returns the hash Hash records for value under key.
+func hashOf(t *testing.T, key, value string) string {
+	t.Helper()
+
+	r := New(Policy{Default: Hash, HashKey: key})
+	hash, _ := r.String("field", value)
+
+	return hash
+}
+
+func TestPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy Policy
+		// errors is the number of invalid actions reported.
+		errors int
+	}{
+		{name: "empty", policy: Policy{}},
+		{name: "every action", policy: Policy{Default: Drop, Fields: map[string]Action{"a": Allow, "b": Hash, "c": Mask, "d": ""}}},
+		{name: "invalid default", policy: Policy{Default: "encrypt"}, errors: 1},
+		{name: "invalid fields", policy: Policy{Fields: map[string]Action{"a": "encrypt", "b": "HASH", "c": Mask}}, errors: 2},
+		{name: "invalid default and field", policy: Policy{Default: "x", Fields: map[string]Action{"a": "y"}}, errors: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.errors == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+
+				return
+			}
+			if !errors.Is(err, ErrInvalidAction) {
+				t.Fatalf("Validate() error = %v, want %v", err, ErrInvalidAction)
+			}
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok || len(joined.Unwrap()) != tc.errors {
+				t.Errorf("Validate() error = %v, want %d errors", err, tc.errors)
+			}
+		})
+	}
+}
+
+func TestRedactorString(t *testing.T) {
+	policy := Policy{
+		Default: Allow,
+		Fields: map[string]Action{
+			"principal": Hash,
+			"a":         Mask,
+			"error":     Drop,
+			"duration":  Drop,
+		},
+		HashKey: "secret",
+	}
+
+	cases := []struct {
+		name  string
+		key   string
+		value string
+		want  string
+		// dropped is true when the field is not recorded.
+		dropped bool
+	}{
+		{name: "allow", key: "result", value: "42", want: "42"},
+		{name: "hash", key: "principal", value: "alice", want: hashOf(t, "secret", "alice")},
+		{name: "mask", key: "a", value: "12345678", want: "******78"},
+		{name: "mask short value", key: "a", value: "123", want: "***"},
+		{name: "mask runes", key: "a", value: "ééééé", want: "****é"},
+		{name: "mask empty value", key: "a", value: "", want: ""},
+		{name: "drop", key: "error", value: "boom", dropped: true},
+		{name: "duration always allowed", key: "duration", value: "1s", want: "1s"},
+	}
+
+	r := New(policy)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := r.String(tc.key, tc.value)
+			if ok == tc.dropped {
+				t.Fatalf("String(%q, %q) recorded = %v, want %v", tc.key, tc.value, ok, !tc.dropped)
+			}
+			if got != tc.want {
+				t.Errorf("String(%q, %q) = %q, want %q", tc.key, tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactorHash(t *testing.T) {
+	alice := hashOf(t, "secret", "alice")
+	if len(alice) != hashLength {
+		t.Errorf("hash %q has length %d, want %d", alice, len(alice), hashLength)
+	}
+	if got := hashOf(t, "secret", "alice"); got != alice {
+		t.Errorf("equal values hash to %q and %q", alice, got)
+	}
+	if got := hashOf(t, "secret", "bob"); got == alice {
+		t.Errorf("different values hash to %q", got)
+	}
+	if got := hashOf(t, "other", "alice"); got == alice {
+		t.Errorf("different keys hash to %q", got)
+	}
+
+	// Without a key, hashes correlate within one redactor only.
+	r := New(Policy{Default: Hash})
+	first, _ := r.String("principal", "alice")
+	second, _ := r.String("principal", "alice")
+	if first != second {
+		t.Errorf("equal values hash to %q and %q", first, second)
+	}
+	if other, _ := New(Policy{Default: Hash}).String("principal", "alice"); other == first {
+		t.Errorf("random keys hash to %q", other)
+	}
+}
+
+func TestRedactorSet(t *testing.T) {
+	r := New(Policy{})
+	if got, _ := r.String("a", "1234"); got != "1234" {
+		t.Errorf("String() = %q, want it allowed", got)
+	}
+
+	r.Set(Policy{Fields: map[string]Action{"a": Mask}})
+	if got, _ := r.String("a", "1234"); got != "***4" {
+		t.Errorf("String() = %q, want it masked", got)
+	}
+}
+
+func TestRedactorNil(t *testing.T) {
+	var r *Redactor
+	if got, ok := r.String("a", "1"); !ok || got != "1" {
+		t.Errorf("String() = %q, %v, want it allowed", got, ok)
+	}
+	if !r.Allowed("a") {
+		t.Error("Allowed() = false, want true")
+	}
+}
+
+func TestRedactorAttr(t *testing.T) {
+	r := New(Policy{
+		Default: Allow,
+		Fields:  map[string]Action{"a": Mask, "principal": Hash, "error": Drop},
+		HashKey: "secret",
+	})
+
+	cases := []struct {
+		name string
+		attr slog.Attr
+		want slog.Attr
+		// dropped is true when the attribute is not recorded.
+		dropped bool
+	}{
+		{name: "allow keeps the kind", attr: slog.Int64("b", 3), want: slog.Int64("b", 3)},
+		{name: "allow duration", attr: slog.Duration("duration", time.Second), want: slog.Duration("duration", time.Second)},
+		{name: "mask", attr: slog.Int64("a", 12345678), want: slog.String("a", "******78")},
+		{name: "hash", attr: slog.String("principal", "alice"), want: slog.String("principal", hashOf(t, "secret", "alice"))},
+		{name: "drop", attr: slog.String("error", "boom"), dropped: true},
+		{
+			name: "group members",
+			attr: slog.Group("request", slog.Int64("a", 1234), slog.Int64("b", 2), slog.String("error", "boom")),
+			want: slog.Group("request", slog.String("a", "***4"), slog.Int64("b", 2)),
+		},
+		{
+			name: "nested group",
+			attr: slog.Group("call", slog.Group("caller", slog.String("principal", "alice"))),
+			want: slog.Group("call", slog.Group("caller", slog.String("principal", hashOf(t, "secret", "alice")))),
+		},
+		{name: "group named as a dropped field", attr: slog.Group("error", slog.Int64("b", 2)), want: slog.Group("error", slog.Int64("b", 2))},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := r.Attr(tc.attr)
+			if ok == tc.dropped {
+				t.Fatalf("Attr(%v) recorded = %v, want %v", tc.attr, ok, !tc.dropped)
+			}
+			if ok && !got.Equal(tc.want) {
+				t.Errorf("Attr(%v) = %v, want %v", tc.attr, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactorKeyValues(t *testing.T) {
+	r := New(Policy{Default: Drop, Fields: map[string]Action{"a": Mask, "result": Allow}})
+
+	got := r.KeyValues(
+		attribute.Int64("a", 1234),
+		attribute.Int64("b", 2),
+		attribute.Int64("result", 1236),
+		attribute.Int64("duration", 5),
+	)
+	want := []attribute.KeyValue{
+		attribute.String("a", "***4"),
+		attribute.Int64("result", 1236),
+		attribute.Int64("duration", 5),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("KeyValues() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("KeyValues()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// attrs is a handler keeping the attributes it is given, as strings keyed by
+// name.
+type attrs map[string]string
+
+func (h attrs) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h attrs) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		h[a.Key] = a.Value.String()
+
+		return true
+	})
+
+	return nil
+}
+
+func (h attrs) WithAttrs(as []slog.Attr) slog.Handler {
+	for _, a := range as {
+		h[a.Key] = a.Value.String()
+	}
+
+	return h
+}
+
+func (h attrs) WithGroup(string) slog.Handler { return h }
+
+func TestRedactorHandler(t *testing.T) {
+	got := attrs{}
+	r := New(Policy{Fields: map[string]Action{"a": Mask, "principal": Drop}})
+	logger := slog.New(r.Handler(got)).With(slog.String("principal", "alice"), slog.Int64("a", 1234))
+	logger.Info("Add", slog.Int64("b", 5678), slog.String("principal", "bob"))
+
+	want := attrs{"a": "***4", "b": "5678"}
+	if len(got) != len(want) {
+		t.Fatalf("logged %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("logged %s = %q, want %q", key, got[key], value)
+		}
+	}
+}
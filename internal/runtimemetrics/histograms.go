@@ -0,0 +1,117 @@
+package runtimemetrics
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	// producerScope differs from the meter scope since the exporter rejects
+	// a scope reported by both the MeterProvider and a Producer.
+	producerScope    = "runtime/metrics"
+	gcPauses         = "/sched/pauses/total/gc:seconds"
+	schedLatencies   = "/sched/latencies:seconds"
+	secondsUnit      = "s"
+	histogramMetrics = 2
+)
+
+// bounds are the histogram bucket boundaries, in seconds, the fine-grained
+// runtime histograms are folded into.
+//
+//nolint:mnd // bucket boundaries
+var bounds = []float64{
+	0, 1e-6, 5e-6, 1e-5, 2.5e-5, 5e-5, 1e-4, 2.5e-4, 5e-4,
+	1e-3, 2.5e-3, 5e-3, 1e-2, 2.5e-2, 5e-2, 0.1, 0.25, 0.5, 1,
+}
+
+type producer struct {
+	start   time.Time
+	sampler *sampler
+}
+
+var _ sdkmetric.Producer = (*producer)(nil)
+
+// NewProducer returns a metric.Producer publishing the GC stop-the-world
+// pause and goroutine scheduling latency histograms of runtime/metrics.
+func NewProducer() sdkmetric.Producer {
+	return &producer{start: time.Now(), sampler: newSampler(gcPauses, schedLatencies)}
+}
+
+func (p *producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	value := p.sampler.read()
+	now := time.Now()
+
+	sm := metricdata.ScopeMetrics{
+		Scope:   instrumentation.Scope{Name: producerScope},
+		Metrics: make([]metricdata.Metrics, 0, histogramMetrics),
+	}
+	for _, m := range []struct {
+		name, description string
+		value             metrics.Value
+	}{
+		{"go.gc.pause.duration", "Distribution of individual GC-related stop-the-world pause latencies.", value(gcPauses)},
+		{"go.schedule.duration", "Time goroutines have spent in the scheduler in a runnable state before actually running.", value(schedLatencies)},
+	} {
+		if m.value.Kind() != metrics.KindFloat64Histogram {
+			continue
+		}
+		sm.Metrics = append(sm.Metrics, metricdata.Metrics{
+			Name:        m.name,
+			Description: m.description,
+			Unit:        secondsUnit,
+			Data: metricdata.Histogram[float64]{
+				Temporality: metricdata.CumulativeTemporality,
+				DataPoints:  []metricdata.HistogramDataPoint[float64]{fold(m.value.Float64Histogram(), p.start, now)},
+			},
+		})
+	}
+
+	return []metricdata.ScopeMetrics{sm}, nil
+}
+
+// fold converts a runtime histogram into an OpenTelemetry data point with
+// the coarser bounds. A runtime bucket is counted in the first bound at or
+// above its upper edge, and the sum is estimated from bucket midpoints.
+func fold(h *metrics.Float64Histogram, start, now time.Time) metricdata.HistogramDataPoint[float64] {
+	dp := metricdata.HistogramDataPoint[float64]{
+		StartTime:    start,
+		Time:         now,
+		Bounds:       bounds,
+		BucketCounts: make([]uint64, len(bounds)+1),
+	}
+
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		lower, upper := h.Buckets[i], h.Buckets[i+1]
+
+		bucket := len(bounds)
+		for j, bound := range bounds {
+			if upper <= bound {
+				bucket = j
+
+				break
+			}
+		}
+		dp.BucketCounts[bucket] += count
+		dp.Count += count
+
+		switch {
+		case math.IsInf(lower, -1):
+			dp.Sum += upper * float64(count)
+		case math.IsInf(upper, 1):
+			dp.Sum += lower * float64(count)
+		default:
+			dp.Sum += (lower + upper) / 2 * float64(count) //nolint:mnd // midpoint
+		}
+	}
+
+	return dp
+}
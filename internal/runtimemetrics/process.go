@@ -0,0 +1,79 @@
+package runtimemetrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/procfs"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// StartProcess registers the process instruments with provider. Names follow
+// the OpenTelemetry semantic conventions for process metrics. It returns an
+// error where /proc is not available.
+func StartProcess(provider metric.MeterProvider) error {
+	proc, err := procfs.Self()
+	if err != nil {
+		return fmt.Errorf("failed to open process stats: %w", err)
+	}
+	if _, err := proc.Stat(); err != nil {
+		return fmt.Errorf("failed to read process stats: %w", err)
+	}
+
+	meter := provider.Meter(scope)
+
+	cpuTime, err := meter.Float64ObservableCounter("process.cpu.time",
+		metric.WithUnit("s"), metric.WithDescription("Total CPU seconds consumed by the process in user and system mode."))
+	if err != nil {
+		return err
+	}
+	memoryUsage, err := meter.Int64ObservableUpDownCounter("process.memory.usage",
+		metric.WithUnit("By"), metric.WithDescription("The amount of physical memory in use (resident set size)."))
+	if err != nil {
+		return err
+	}
+	memoryVirtual, err := meter.Int64ObservableUpDownCounter("process.memory.virtual",
+		metric.WithUnit("By"), metric.WithDescription("The amount of committed virtual memory."))
+	if err != nil {
+		return err
+	}
+	openFDs, err := meter.Int64ObservableUpDownCounter("process.open_file_descriptor.count",
+		metric.WithUnit("{count}"), metric.WithDescription("Number of file descriptors in use by the process."))
+	if err != nil {
+		return err
+	}
+	maxFDs, err := meter.Int64ObservableUpDownCounter("process.open_file_descriptor.limit",
+		metric.WithUnit("{count}"), metric.WithDescription("Soft limit on the number of file descriptors of the process."))
+	if err != nil {
+		return err
+	}
+	threads, err := meter.Int64ObservableUpDownCounter("process.thread.count",
+		metric.WithUnit("{thread}"), metric.WithDescription("Process threads count."))
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		// Each reading is reported independently so that one unreadable
+		// file does not hide the others.
+		if stat, err := proc.Stat(); err == nil {
+			o.ObserveFloat64(cpuTime, stat.CPUTime())
+			o.ObserveInt64(memoryUsage, int64(stat.ResidentMemory()))
+			o.ObserveInt64(memoryVirtual, int64(stat.VirtualMemory())) //nolint:gosec // fits in int64
+			o.ObserveInt64(threads, int64(stat.NumThreads))
+		}
+		if n, err := proc.FileDescriptorsLen(); err == nil {
+			o.ObserveInt64(openFDs, int64(n))
+		}
+		if limits, err := proc.Limits(); err == nil && limits.OpenFiles > 0 {
+			o.ObserveInt64(maxFDs, int64(limits.OpenFiles)) //nolint:gosec // fits in int64
+		}
+
+		return nil
+	}, cpuTime, memoryUsage, memoryVirtual, openFDs, maxFDs, threads)
+	if err != nil {
+		return fmt.Errorf("failed to register process metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package runtimemetrics reports Go runtime and process metrics through an
+// OpenTelemetry MeterProvider so that they can be correlated with the gRPC
+// metrics, traces and profiles of the same process.
+//
+// Gauges and counters are read from runtime/metrics and /proc when the
+// provider collects. The GC pause and scheduler latency histograms are
+// published by a metric.Producer, since the OpenTelemetry API has no
+// asynchronous histogram instrument.
+package runtimemetrics
+
+import (
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const scope = "github.com/rodneyosodo/gophercon/internal/runtimemetrics"
+
+const (
+	goroutines     = "/sched/goroutines:goroutines"
+	gomaxprocs     = "/sched/gomaxprocs:threads"
+	gogc           = "/gc/gogc:percent"
+	gomemlimit     = "/gc/gomemlimit:bytes"
+	heapGoal       = "/gc/heap/goal:bytes"
+	heapAllocBytes = "/gc/heap/allocs:bytes"
+	heapAllocs     = "/gc/heap/allocs:objects"
+	gcCycles       = "/gc/cycles/total:gc-cycles"
+	heapObjects    = "/memory/classes/heap/objects:bytes"
+	heapReleased   = "/memory/classes/heap/released:bytes"
+	heapStacks     = "/memory/classes/heap/stacks:bytes"
+	osStacks       = "/memory/classes/os-stacks:bytes"
+	totalMemory    = "/memory/classes/total:bytes"
+)
+
+// sampler reads a fixed set of runtime/metrics samples. It is safe for
+// concurrent use.
+type sampler struct {
+	mu      sync.Mutex
+	samples []metrics.Sample
+	index   map[string]int
+}
+
+func newSampler(names ...string) *sampler {
+	s := &sampler{samples: make([]metrics.Sample, len(names)), index: map[string]int{}}
+	for i, name := range names {
+		s.samples[i].Name = name
+		s.index[name] = i
+	}
+
+	return s
+}
+
+// read refreshes the samples and returns a lookup of their values.
+func (s *sampler) read() func(name string) metrics.Value {
+	s.mu.Lock()
+	metrics.Read(s.samples)
+	values := make([]metrics.Value, len(s.samples))
+	for i, sample := range s.samples {
+		values[i] = sample.Value
+	}
+	s.mu.Unlock()
+
+	return func(name string) metrics.Value {
+		return values[s.index[name]]
+	}
+}
+
+func uint64Of(v metrics.Value) int64 {
+	if v.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return int64(min(v.Uint64(), 1<<63-1)) //nolint:gosec,mnd // clamped to int64
+}
+
+// StartRuntime registers the Go runtime instruments with provider. Names
+// follow the OpenTelemetry semantic conventions for Go runtime metrics.
+func StartRuntime(provider metric.MeterProvider) error {
+	meter := provider.Meter(scope)
+	s := newSampler(goroutines, gomaxprocs, gogc, gomemlimit, heapGoal, heapAllocBytes,
+		heapAllocs, gcCycles, heapObjects, heapReleased, heapStacks, osStacks, totalMemory)
+
+	goroutineCount, err := meter.Int64ObservableUpDownCounter("go.goroutine.count",
+		metric.WithUnit("{goroutine}"), metric.WithDescription("Count of live goroutines."))
+	if err != nil {
+		return err
+	}
+	processorLimit, err := meter.Int64ObservableUpDownCounter("go.processor.limit",
+		metric.WithUnit("{thread}"), metric.WithDescription("The number of OS threads that can execute user-level Go code simultaneously (GOMAXPROCS)."))
+	if err != nil {
+		return err
+	}
+	configGOGC, err := meter.Int64ObservableUpDownCounter("go.config.gogc",
+		metric.WithUnit("%"), metric.WithDescription("Heap size target percentage configured by GOGC."))
+	if err != nil {
+		return err
+	}
+	memoryLimit, err := meter.Int64ObservableUpDownCounter("go.memory.limit",
+		metric.WithUnit("By"), metric.WithDescription("Go runtime memory limit configured by GOMEMLIMIT."))
+	if err != nil {
+		return err
+	}
+	memoryUsed, err := meter.Int64ObservableUpDownCounter("go.memory.used",
+		metric.WithUnit("By"), metric.WithDescription("Memory used by the Go runtime, excluding memory released to the OS."))
+	if err != nil {
+		return err
+	}
+	heapUsed, err := meter.Int64ObservableUpDownCounter("go.memory.heap.used",
+		metric.WithUnit("By"), metric.WithDescription("Memory occupied by live and not yet swept heap objects."))
+	if err != nil {
+		return err
+	}
+	gcGoal, err := meter.Int64ObservableUpDownCounter("go.memory.gc.goal",
+		metric.WithUnit("By"), metric.WithDescription("Heap size target for the end of the GC cycle."))
+	if err != nil {
+		return err
+	}
+	allocated, err := meter.Int64ObservableCounter("go.memory.allocated",
+		metric.WithUnit("By"), metric.WithDescription("Memory allocated to the heap by the application."))
+	if err != nil {
+		return err
+	}
+	allocations, err := meter.Int64ObservableCounter("go.memory.allocations",
+		metric.WithUnit("{allocation}"), metric.WithDescription("Count of allocations to the heap by the application."))
+	if err != nil {
+		return err
+	}
+	gcCount, err := meter.Int64ObservableCounter("go.gc.count",
+		metric.WithUnit("{gc_cycle}"), metric.WithDescription("Count of completed GC cycles."))
+	if err != nil {
+		return err
+	}
+
+	stack := metric.WithAttributes(attribute.String("go.memory.type", "stack"))
+	other := metric.WithAttributes(attribute.String("go.memory.type", "other"))
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		value := s.read()
+
+		stacks := uint64Of(value(heapStacks)) + uint64Of(value(osStacks))
+		used := uint64Of(value(totalMemory)) - uint64Of(value(heapReleased))
+
+		o.ObserveInt64(goroutineCount, uint64Of(value(goroutines)))
+		o.ObserveInt64(processorLimit, uint64Of(value(gomaxprocs)))
+		o.ObserveInt64(configGOGC, uint64Of(value(gogc)))
+		o.ObserveInt64(memoryLimit, uint64Of(value(gomemlimit)))
+		o.ObserveInt64(memoryUsed, stacks, stack)
+		o.ObserveInt64(memoryUsed, used-stacks, other)
+		o.ObserveInt64(heapUsed, uint64Of(value(heapObjects)))
+		o.ObserveInt64(gcGoal, uint64Of(value(heapGoal)))
+		o.ObserveInt64(allocated, uint64Of(value(heapAllocBytes)))
+		o.ObserveInt64(allocations, uint64Of(value(heapAllocs)))
+		o.ObserveInt64(gcCount, uint64Of(value(gcCycles)))
+
+		return nil
+	}, goroutineCount, processorLimit, configGOGC, memoryLimit, memoryUsed, heapUsed, gcGoal, allocated, allocations, gcCount)
+	if err != nil {
+		return fmt.Errorf("failed to register runtime metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package slo
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	durationMetric = "grpc_server_call_duration_seconds"
+	errorRatio     = "slo:sli_error:ratio_rate"
+	header         = "# Code generated by cmd/slo from the objectives file. DO NOT EDIT.\n"
+)
+
+// burnAlert is one multi-window burn-rate condition, as recommended by the
+// Google SRE workbook: it holds when the error ratio over both the long and
+// the short window is high enough to spend the given share of the error
+// budget within the long window.
+type burnAlert struct {
+	severity string
+	long     time.Duration
+	short    time.Duration
+	budget   float64
+}
+
+//nolint:mnd // SRE workbook windows and budget shares
+var burnAlerts = []burnAlert{
+	{severity: "page", long: time.Hour, short: 5 * time.Minute, budget: 0.02},
+	{severity: "page", long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05},
+	{severity: "ticket", long: 24 * time.Hour, short: 2 * time.Hour, budget: 0.1},
+	{severity: "ticket", long: 72 * time.Hour, short: 6 * time.Hour, budget: 0.1},
+}
+
+// RuleFile is a Prometheus rule file.
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup is a named group of rules evaluated together.
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a Prometheus recording or alerting rule.
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Rules returns one rule group per objective holding its error ratio
+// recording rules, its target and its burn-rate alerts.
+func (c *Config) Rules() RuleFile {
+	var file RuleFile
+	for _, o := range c.Objectives {
+		labels := map[string]string{
+			"slo":       o.Name,
+			"slo_type":  string(o.Type),
+			"operation": o.Operation,
+		}
+
+		group := RuleGroup{Name: "slo-" + o.Name}
+		for _, window := range windows() {
+			group.Rules = append(group.Rules, Rule{
+				Record: errorRatio + promDuration(window),
+				Expr:   c.errorRatioExpr(o, window),
+				Labels: labels,
+			})
+		}
+		group.Rules = append(group.Rules,
+			Rule{Record: "slo:objective:ratio", Expr: fmt.Sprintf("vector(%s)", formatFloat(o.Target)), Labels: labels},
+			Rule{Record: "slo:error_budget:ratio", Expr: fmt.Sprintf("vector(%s)", formatFloat(1-o.Target)), Labels: labels},
+		)
+
+		for _, severity := range []string{"page", "ticket"} {
+			group.Rules = append(group.Rules, c.alert(o, severity))
+		}
+		file.Groups = append(file.Groups, group)
+	}
+
+	return file
+}
+
+// Marshal renders the rule file as YAML.
+func (f RuleFile) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2) //nolint:mnd // indentation of the existing configuration files
+	if err := encoder.Encode(f); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Config) errorRatioExpr(o Objective, window time.Duration) string {
+	method := "grpc_method=" + strconv.Quote(c.method(o))
+	total := fmt.Sprintf("sum(rate(%s_count{%s}[%s]))", durationMetric, method, promDuration(window))
+
+	switch o.Type {
+	case Latency:
+		le := "le=" + strconv.Quote(formatFloat(o.Threshold.Seconds()))
+		good := fmt.Sprintf("sum(rate(%s_bucket{%s,%s}[%s]))", durationMetric, method, le, promDuration(window))
+
+		return fmt.Sprintf("1 - (\n  %s\n  /\n  %s\n)", good, total)
+	default:
+		codes := "grpc_status=~" + strconv.Quote(strings.Join(o.ErrorCodes, "|"))
+		bad := fmt.Sprintf("sum(rate(%s_count{%s,%s}[%s]))", durationMetric, method, codes, promDuration(window))
+
+		return fmt.Sprintf("(%s or vector(0))\n/\n%s", bad, total)
+	}
+}
+
+// alert returns the alert of severity, which fires when any of its
+// multi-window burn-rate conditions holds.
+func (c *Config) alert(o Objective, severity string) Rule {
+	selector := fmt.Sprintf("{slo=%s}", strconv.Quote(o.Name))
+
+	var conditions, rates []string
+	for _, b := range burnAlerts {
+		if b.severity != severity {
+			continue
+		}
+		burn := b.budget * c.Window.Hours() / b.long.Hours()
+		threshold := fmt.Sprintf("(%s * %s)", formatFloat(burn), formatFloat(1-o.Target))
+		conditions = append(conditions, fmt.Sprintf("(\n  %s%s%s > %s\n  and\n  %s%s%s > %s\n)",
+			errorRatio, promDuration(b.long), selector, threshold,
+			errorRatio, promDuration(b.short), selector, threshold,
+		))
+		rates = append(rates, fmt.Sprintf("%sx over %s", formatFloat(burn), promDuration(b.long)))
+	}
+
+	forDuration := "2m"
+	if severity == "ticket" {
+		forDuration = "15m"
+	}
+
+	annotations := map[string]string{
+		"summary": fmt.Sprintf("%s is burning its error budget too fast", o.Name),
+		"description": fmt.Sprintf("%s %s of %s (target %s over %s) is burning its error budget at more than %s.",
+			o.Operation, o.Type, c.Service, formatFloat(o.Target), promDuration(c.Window), strings.Join(rates, " or ")),
+	}
+	if o.Description != "" {
+		annotations["objective"] = o.Description
+	}
+
+	return Rule{
+		Alert: "SLOErrorBudgetBurn",
+		Expr:  strings.Join(conditions, "\nor\n"),
+		For:   forDuration,
+		Labels: map[string]string{
+			"severity":  severity,
+			"slo":       o.Name,
+			"slo_type":  string(o.Type),
+			"operation": o.Operation,
+		},
+		Annotations: annotations,
+	}
+}
+
+// windows returns every window an error ratio is recorded over, shortest first.
+func windows() []time.Duration {
+	var result []time.Duration
+	seen := map[time.Duration]bool{}
+	for _, b := range burnAlerts {
+		for _, w := range []time.Duration{b.short, b.long} {
+			if !seen[w] {
+				seen[w] = true
+				result = append(result, w)
+			}
+		}
+	}
+	slices.Sort(result)
+
+	return result
+}
+
+// promDuration formats d in the largest whole Prometheus duration unit.
+func promDuration(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+func formatFloat(f float64) string {
+	// Ten significant digits hide binary rounding such as 1-0.999.
+	return strconv.FormatFloat(f, 'g', 10, 64)
+}
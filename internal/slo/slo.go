@@ -0,0 +1,157 @@
+// Package slo describes per-operation service level objectives and generates
+// the Prometheus recording and multi-window, multi-burn-rate alerting rules
+// that track them.
+package slo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/stats/opentelemetry"
+	"gopkg.in/yaml.v3"
+)
+
+// Type is the kind of service level indicator an objective is measured with.
+type Type string
+
+const (
+	// Availability is the share of calls that did not fail with a server error.
+	Availability Type = "availability"
+	// Latency is the share of calls that completed within a threshold.
+	Latency Type = "latency"
+)
+
+const (
+	defaultService = "calculator.Calculator"
+	defaultWindow  = 30 * 24 * time.Hour
+	minWindow      = 24 * time.Hour
+)
+
+// DefaultErrorCodes are the gRPC status codes counted as failures by
+// availability objectives. Codes caused by the caller, such as
+// InvalidArgument or PermissionDenied, do not spend the error budget.
+var DefaultErrorCodes = []string{
+	"UNKNOWN",
+	"DEADLINE_EXCEEDED",
+	"RESOURCE_EXHAUSTED",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+}
+
+// Config is a set of objectives, e.g.:
+//
+//	service: calculator.Calculator
+//	window: 720h
+//	objectives:
+//	  - {name: divide-availability, operation: Divide, type: availability, target: 0.99}
+//	  - {name: divide-latency, operation: Divide, type: latency, target: 0.99, threshold: 100ms}
+type Config struct {
+	// Service is the fully qualified gRPC service name.
+	Service string `yaml:"service"`
+	// Window is the compliance period of every objective, 30 days by default.
+	Window     time.Duration `yaml:"window"`
+	Objectives []Objective   `yaml:"objectives"`
+}
+
+// Objective is the target share of good calls of one operation.
+type Objective struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Operation   string  `yaml:"operation"`
+	Type        Type    `yaml:"type"`
+	Target      float64 `yaml:"target"`
+	// Threshold is the latency a call must complete within. It must be one
+	// of the bucket boundaries of the gRPC server call duration histogram.
+	Threshold time.Duration `yaml:"threshold"`
+	// ErrorCodes overrides DefaultErrorCodes for availability objectives.
+	ErrorCodes []string `yaml:"error_codes"`
+}
+
+// Load reads and validates a YAML objectives file.
+func Load(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objectives: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse objectives: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks the objectives and fills in defaults.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Service == "" {
+		c.Service = defaultService
+	}
+	if c.Window == 0 {
+		c.Window = defaultWindow
+	}
+	if c.Window < minWindow {
+		errs = append(errs, fmt.Errorf("window must be at least %s", minWindow))
+	}
+	if len(c.Objectives) == 0 {
+		errs = append(errs, errors.New("no objectives defined"))
+	}
+
+	names := map[string]bool{}
+	for i := range c.Objectives {
+		o := &c.Objectives[i]
+		if o.Name == "" {
+			errs = append(errs, fmt.Errorf("objective %d: name is required", i+1))
+		}
+		if names[o.Name] {
+			errs = append(errs, fmt.Errorf("objective %s: duplicate name", o.Name))
+		}
+		names[o.Name] = true
+
+		if o.Operation == "" {
+			errs = append(errs, fmt.Errorf("objective %s: operation is required", o.Name))
+		}
+		if o.Target <= 0 || o.Target >= 1 {
+			errs = append(errs, fmt.Errorf("objective %s: target must be between 0 and 1 exclusive", o.Name))
+		}
+
+		switch o.Type {
+		case Availability:
+			if len(o.ErrorCodes) == 0 {
+				o.ErrorCodes = slices.Clone(DefaultErrorCodes)
+			}
+			for j, code := range o.ErrorCodes {
+				o.ErrorCodes[j] = strings.ToUpper(code)
+			}
+		case Latency:
+			if !slices.Contains(opentelemetry.DefaultLatencyBounds, o.Threshold.Seconds()) || o.Threshold <= 0 {
+				errs = append(errs, fmt.Errorf("objective %s: threshold %s is not a histogram bucket boundary", o.Name, o.Threshold))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("objective %s: unknown type %q", o.Name, o.Type))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// method returns the value of the grpc_method label of the operation.
+func (c *Config) method(o Objective) string {
+	return c.Service + "/" + o.Operation
+}
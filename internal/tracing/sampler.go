@@ -0,0 +1,39 @@
+// Package tracing holds OpenTelemetry tracing helpers of the server.
+package tracing
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RatioSampler samples a share of traces by trace ID. The ratio can be
+// changed while spans are started.
+type RatioSampler struct {
+	sampler atomic.Pointer[sdktrace.Sampler]
+}
+
+var _ sdktrace.Sampler = (*RatioSampler)(nil)
+
+// NewRatioSampler returns a RatioSampler sampling ratio of the traces.
+func NewRatioSampler(ratio float64) *RatioSampler {
+	s := &RatioSampler{}
+	s.SetRatio(ratio)
+
+	return s
+}
+
+// SetRatio changes the share of traces sampled.
+func (s *RatioSampler) SetRatio(ratio float64) {
+	sampler := sdktrace.TraceIDRatioBased(ratio)
+	s.sampler.Store(&sampler)
+}
+
+func (s *RatioSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return (*s.sampler.Load()).ShouldSample(p)
+}
+
+func (s *RatioSampler) Description() string {
+	return fmt.Sprintf("Reloadable{%s}", (*s.sampler.Load()).Description())
+}
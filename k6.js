@@ -2,7 +2,28 @@ import grpc from "k6/net/grpc";
 import { check, sleep } from "k6";
 
 const client = new grpc.Client();
-client.load(["calculator"], "./calculator.proto");
+client.load(["calculator", "third_party/googleapis"], "calculator.proto");
+
+// Set K6_GRPC_CA_CERT to the server's CA to connect over TLS, and
+// K6_GRPC_CLIENT_CERT / K6_GRPC_CLIENT_KEY as well when mTLS is enabled.
+const address = __ENV.K6_GRPC_ADDR || "localhost:6000";
+const caCert = __ENV.K6_GRPC_CA_CERT ? open(__ENV.K6_GRPC_CA_CERT) : "";
+const clientCert = __ENV.K6_GRPC_CLIENT_CERT ? open(__ENV.K6_GRPC_CLIENT_CERT) : "";
+const clientKey = __ENV.K6_GRPC_CLIENT_KEY ? open(__ENV.K6_GRPC_CLIENT_KEY) : "";
+
+function connectParams() {
+  if (!caCert) {
+    return { plaintext: true };
+  }
+
+  const tls = { cacerts: [caCert] };
+  if (clientCert && clientKey) {
+    tls.cert = clientCert;
+    tls.key = clientKey;
+  }
+
+  return { plaintext: false, tls: tls };
+}
 
 export const options = {
   vus: 100,
@@ -18,9 +39,7 @@ export default () => {
 };
 
 function performOperation(method) {
-  client.connect("localhost:6000", {
-    plaintext: true,
-  });
+  client.connect(address, connectParams());
 
   const data = { a: 18, b: 3 };
   const func = "calculator.Calculator/" + method;